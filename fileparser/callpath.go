@@ -0,0 +1,240 @@
+package fileparser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 调用图中两个函数之间最短的调用路径，没有路径时返回nil
+func (g *Graph) ShortestPath(from string, to string) []Edge {
+	paths := g.ShortestPaths(from, to, 1)
+	if len(paths) == 0 {
+		return nil
+	}
+	return paths[0]
+}
+
+// 调用图中两个函数之间最短的k条路径，路径中不会重复经过同一个函数，按长度排序
+func (g *Graph) ShortestPaths(from string, to string, k int) [][]Edge {
+	from, to = trimQuote(from), trimQuote(to)
+	result := make([][]Edge, 0)
+	if _, ok := g.nodes[from]; !ok || from == to || k <= 0 {
+		return result
+	}
+	if _, ok := g.nodes[to]; !ok {
+		return result
+	}
+
+	for _, path := range g.getShortestPaths(from, to, k) {
+		edges := make([]Edge, 0, len(path)-1)
+		for i := 0; i+1 < len(path); i++ {
+			edges = append(edges, g.getCallEdge(path[i], path[i+1]).clone())
+		}
+		result = append(result, edges)
+	}
+	return result
+}
+
+// Yen算法，每次在已有路径的基础上，从每个分叉点出发找一条绕开已有路径的最短路径
+func (g *Graph) getShortestPaths(from string, to string, k int) [][]string {
+	first := g.getCallPath(from, to, map[string]bool{}, map[string]bool{})
+	if first == nil {
+		return nil
+	}
+	paths := [][]string{first}
+	candidates := make([][]string, 0)
+	found := map[string]bool{strings.Join(first, "\n"): true}
+
+	for len(paths) < k {
+		last := paths[len(paths)-1]
+		for i := 0; i+1 < len(last); i++ {
+			spur, root := last[i], last[:i+1]
+			removedEdges := make(map[string]bool, 0)
+			for _, path := range paths {
+				if len(path) > i+1 && isSamePath(path[:i+1], root) {
+					removedEdges[path[i]+"\n"+path[i+1]] = true
+				}
+			}
+			removedNodes := make(map[string]bool, 0)
+			for _, node := range root[:i] {
+				removedNodes[node] = true
+			}
+
+			spurPath := g.getCallPath(spur, to, removedNodes, removedEdges)
+			if spurPath == nil {
+				continue
+			}
+			path := append(append([]string{}, root[:i]...), spurPath...)
+			if key := strings.Join(path, "\n"); !found[key] {
+				found[key] = true
+				candidates = append(candidates, path)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		// 长度相同时按经过的函数排序，保证每次输出一致
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if len(candidates[i]) != len(candidates[j]) {
+				return len(candidates[i]) < len(candidates[j])
+			}
+			return strings.Join(candidates[i], "\n") < strings.Join(candidates[j], "\n")
+		})
+		paths = append(paths, candidates[0])
+		candidates = candidates[1:]
+	}
+	return paths
+}
+
+// 广度优先查找最短路径，跳过去掉的节点以及边，同一层按ID的顺序扩展
+func (g *Graph) getCallPath(from string, to string, removedNodes map[string]bool, removedEdges map[string]bool) []string {
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			path := make([]string, 0)
+			for node := to; node != ""; node = previous[node] {
+				path = append([]string{node}, path...)
+			}
+			return path
+		}
+		for _, next := range g.getCallees(current) {
+			if _, ok := previous[next]; ok || removedNodes[next] || removedEdges[current+"\n"+next] {
+				continue
+			}
+			previous[next] = current
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+func (g *Graph) getCallees(id string) []string {
+	result := make([]string, 0)
+	for _, edge := range g.out[id] {
+		if edge.Kind.IsCall() {
+			result = append(result, edge.To)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (g *Graph) getCallEdge(from string, to string) *Edge {
+	for _, edge := range g.out[from] {
+		if edge.Kind.IsCall() && edge.To == to {
+			return edge
+		}
+	}
+	return &Edge{Kind: EdgeCall, From: from, To: to}
+}
+
+func isSamePath(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 从source到target最短的k条调用路径，每条路径是依次经过的函数
+func (n *NodeManager) GetCallPaths(source string, target string, k int) [][]string {
+	result := make([][]string, 0)
+	for _, path := range n.getCallPaths(source, target, k) {
+		names := []string{n.getFunctionDisplayName(path[0].From)}
+		for _, edge := range path {
+			names = append(names, n.getFunctionDisplayName(edge.To))
+		}
+		result = append(result, names)
+	}
+	return result
+}
+
+func (n *NodeManager) getCallPaths(source string, target string, k int) [][]Edge {
+	sourceNode := n.getMatchedFunction(source)
+	targetNode := n.getMatchedFunction(target)
+	if sourceNode == nil || targetNode == nil {
+		return nil
+	}
+	return n.Graph().ShortestPaths(sourceNode.getIdentity(), targetNode.getIdentity(), k)
+}
+
+func (n *NodeManager) getFunctionDisplayName(id string) string {
+	if node, ok := n.Graph().Node(id); ok {
+		return node.Name
+	}
+	return id
+}
+
+// 只绘制source到target最短的k条调用路径，最短的路径用粗线表示
+func (n *NodeManager) DrawCallPath(source string, target string, k int) {
+	paths := n.getCallPaths(source, target, k)
+	if len(paths) == 0 {
+		Log.Sugar().Warnf("no call path from %s to %s", source, target)
+		return
+	}
+
+	functionNodes := make(map[string]*FunctionNode, 0)
+	for _, nodes := range n.allFunctions {
+		for _, node := range nodes {
+			functionNodes[trimQuote(node.getIdentity())] = node
+		}
+	}
+
+	content := n.newGraph()
+	record := make(map[string]bool, 0)
+	drawNode := func(id string) {
+		if node, ok := functionNodes[id]; ok && !record[id] {
+			content.WriteString(fmt.Sprintf("%s [label=%s, %s];\n", node.getIdentity(), node.getLabel(), node.getNodeAttributes()))
+			record[id] = true
+		}
+	}
+	edgeRecord := make(map[string]bool, 0)
+	for i, path := range paths {
+		drawNode(path[0].From)
+		for _, edge := range path {
+			drawNode(edge.To)
+			key := edge.From + "\n" + edge.To
+			if edgeRecord[key] {
+				continue
+			}
+			edgeRecord[key] = true
+			content.WriteString(fmt.Sprintf("\"%s\"->\"%s\"%s;\n", edge.From, edge.To, n.getCallPathAttributes(functionNodes[edge.From], edge, i == 0)))
+		}
+	}
+	content.WriteString("}")
+
+	from := strings.ReplaceAll(trimQuote(functionNodes[paths[0][0].From].getDisplayName()), "/", "_")
+	to := strings.ReplaceAll(trimQuote(functionNodes[paths[0][len(paths[0])-1].To].getDisplayName()), "/", "_")
+	n.writeGraph("call_path_"+from+"_to_"+to, content)
+}
+
+// 调用边沿用调用图中的样式，接口分派用空心箭头
+func (n *NodeManager) getCallPathAttributes(caller *FunctionNode, edge Edge, shortest bool) string {
+	attributes := ""
+	if edge.Kind == EdgeDispatch {
+		color := ""
+		if isSameCycle(n.callCycleOf, "\""+edge.From+"\"", "\""+edge.To+"\"") {
+			color = ", color=\"red\""
+		}
+		attributes = " [style=\"dashed\", arrowhead=\"empty\", label=\"dispatch\"" + color + "]"
+	} else if caller != nil {
+		if callEdge, ok := caller.calleeEdges["\""+edge.To+"\""]; ok {
+			attributes = callEdge.getAttributes()
+		}
+	}
+	if !shortest {
+		return attributes
+	}
+	if attributes == "" {
+		return " [penwidth=\"2\"]"
+	}
+	return strings.TrimSuffix(attributes, "]") + ", penwidth=\"2\"]"
+}
@@ -0,0 +1,194 @@
+package fileparser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// 在临时目录中写入源码并解析，files的key为相对于模块根目录的路径，没有开启WithTests时跳过测试文件
+func parseSource(t *testing.T, files map[string]string, options ...Option) *NodeManager {
+	t.Helper()
+	root := t.TempDir()
+	config := NewConfig(options...)
+	names := make([]string, 0, len(files))
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(name, ".go") && (config.Tests || !strings.HasSuffix(name, "_test.go")) {
+			names = append(names, path)
+		}
+	}
+	sort.Strings(names)
+
+	nodeManager := NewParser(root, options...).(*NodeManager)
+	for _, name := range names {
+		if err := nodeManager.Inspect(name); err != nil {
+			t.Fatalf("inspect %s: %v", name, err)
+		}
+	}
+	nodeManager.Merge()
+	return nodeManager
+}
+
+func TestShortestPaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][2]string
+		from  string
+		to    string
+		k     int
+		want  [][]string
+	}{
+		{
+			name:  "按长度排序，长度相同时按经过的函数排序",
+			edges: [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"d", "e"}, {"b", "e"}},
+			from:  "a", to: "e", k: 3,
+			want: [][]string{{"a", "b", "e"}, {"a", "b", "d", "e"}, {"a", "c", "d", "e"}},
+		},
+		{
+			name:  "k大于路径数量时返回全部路径",
+			edges: [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"d", "e"}, {"b", "e"}},
+			from:  "a", to: "e", k: 10,
+			want: [][]string{{"a", "b", "e"}, {"a", "b", "d", "e"}, {"a", "c", "d", "e"}},
+		},
+		{
+			name: "不同分叉点得到的相同路径只保留一条",
+			edges: [][2]string{
+				{"a", "b"}, {"a", "c"}, {"b", "c"}, {"c", "b"},
+				{"b", "d"}, {"c", "d"},
+			},
+			from: "a", to: "d", k: 10,
+			want: [][]string{{"a", "b", "d"}, {"a", "c", "d"}, {"a", "b", "c", "d"}, {"a", "c", "b", "d"}},
+		},
+		{
+			name:  "路径中不重复经过同一个函数",
+			edges: [][2]string{{"a", "b"}, {"b", "a"}, {"b", "c"}, {"c", "b"}},
+			from:  "a", to: "c", k: 5,
+			want: [][]string{{"a", "b", "c"}},
+		},
+		{
+			name:  "自环不影响路径",
+			edges: [][2]string{{"a", "a"}, {"a", "b"}},
+			from:  "a", to: "b", k: 2,
+			want: [][]string{{"a", "b"}},
+		},
+		{
+			name:  "没有路径",
+			edges: [][2]string{{"a", "b"}, {"c", "b"}},
+			from:  "a", to: "c", k: 3,
+			want: [][]string{},
+		},
+		{
+			name:  "起点和终点相同",
+			edges: [][2]string{{"a", "a"}},
+			from:  "a", to: "a", k: 3,
+			want: [][]string{},
+		},
+		{
+			name:  "k为0",
+			edges: [][2]string{{"a", "b"}},
+			from:  "a", to: "b", k: 0,
+			want: [][]string{},
+		},
+		{
+			name:  "不存在的函数",
+			edges: [][2]string{{"a", "b"}},
+			from:  "a", to: "x", k: 1,
+			want: [][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := newGraph()
+			for _, edge := range tt.edges {
+				for _, id := range edge {
+					if _, ok := graph.nodes[id]; !ok {
+						graph.addNode(&Node{Kind: NodeFunction, ID: id, Name: id})
+					}
+				}
+				graph.addEdge(EdgeCall, edge[0], edge[1], "")
+			}
+
+			got := make([][]string, 0)
+			for _, path := range graph.ShortestPaths(tt.from, tt.to, tt.k) {
+				ids := []string{path[0].From}
+				for _, edge := range path {
+					ids = append(ids, edge.To)
+				}
+				got = append(got, ids)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ShortestPaths(%s, %s, %d) = %v, want %v", tt.from, tt.to, tt.k, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortestPathEdges(t *testing.T) {
+	graph := newGraph()
+	for _, id := range []string{"a", "i", "b", "s"} {
+		graph.addNode(&Node{Kind: NodeFunction, ID: id, Name: id})
+	}
+	graph.addEdge(EdgeGo, "a", "i", "")
+	graph.addEdge(EdgeDispatch, "i", "b", "")
+	// 不是调用关系的边不算路径
+	graph.addEdge(EdgeField, "a", "b", "")
+	graph.addEdge(EdgeCall, "\"a\"", "\"s\"", "")
+
+	path := graph.ShortestPath("\"a\"", "b")
+	kinds := make([]EdgeKind, 0)
+	for _, edge := range path {
+		kinds = append(kinds, edge.Kind)
+	}
+	if want := []EdgeKind{EdgeGo, EdgeDispatch}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("ShortestPath(a, b) kinds = %v, want %v", kinds, want)
+	}
+	if path := graph.ShortestPath("b", "a"); path != nil {
+		t.Errorf("ShortestPath(b, a) = %v, want nil", path)
+	}
+}
+
+func TestGetCallPaths(t *testing.T) {
+	nodeManager := parseSource(t, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"main.go": `package main
+
+type Runner interface{ Run() }
+
+type job struct{}
+
+func (job) Run() { save() }
+
+func main() {
+	var r Runner = job{}
+	r.Run()
+	direct()
+}
+
+func direct() { save() }
+
+func save() {}
+`,
+	})
+
+	got := nodeManager.GetCallPaths("main//main", "main//save", 3)
+	want := [][]string{
+		{"main//main", "main//direct", "main//save"},
+		{"main//main", "main/Runner/Run", "main/job/Run", "main//save"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetCallPaths(main, save) = %v, want %v", got, want)
+	}
+	if got := nodeManager.GetCallPaths("main//save", "main//main", 3); len(got) != 0 {
+		t.Errorf("GetCallPaths(save, main) = %v, want empty", got)
+	}
+}
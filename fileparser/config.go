@@ -0,0 +1,110 @@
+package fileparser
+
+import (
+	"fmt"
+	"go/build"
+	"path/filepath"
+	"strings"
+)
+
+// 解析项目时使用的配置
+type Config struct {
+	// 目标平台以及构建标签，决定哪些文件参与解析
+	BuildContext build.Context
+	// 是否解析_test.go文件
+	Tests bool
+	// gitignore格式的包含以及排除规则，排除规则在忽略文件之后生效
+	Include []string
+	Exclude []string
+	// 目录的最大搜索深度
+	MaxDepth int
+	// 严格模式下任何文件解析失败都返回错误，否则跳过错误继续解析
+	Strict bool
+	// 图中节点链接到源码的URL模板
+	SourceURL string
+	// 节点label中是否展示注释的摘要
+	DocSummary bool
+	// 死代码分析的入口
+	DeadCodeRoots []DeadCodeRoot
+}
+
+type Option func(*Config)
+
+func NewConfig(options ...Option) *Config {
+	config := &Config{
+		BuildContext: build.Default,
+		MaxDepth:     100,
+		SourceURL:    defaultSourceURL,
+		DocSummary:   true,
+		// 默认入口
+		DeadCodeRoots: append([]DeadCodeRoot{}, defaultDeadCodeRoots...),
+	}
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// 指定目标平台以及构建标签，和go build的文件选择保持一致
+func WithBuildContext(goos string, goarch string, tags ...string) Option {
+	return func(config *Config) {
+		if goos != "" {
+			config.BuildContext.GOOS = goos
+		}
+		if goarch != "" {
+			config.BuildContext.GOARCH = goarch
+		}
+		config.BuildContext.BuildTags = append([]string{}, tags...)
+	}
+}
+
+// 同时解析测试文件，包括内部测试以及外部的_test包
+func WithTests() Option {
+	return func(config *Config) {
+		config.Tests = true
+	}
+}
+
+// 只解析匹配这些规则的文件
+func WithInclude(patterns ...string) Option {
+	return func(config *Config) {
+		config.Include = append(config.Include, patterns...)
+	}
+}
+
+// 跳过匹配这些规则的文件以及目录
+func WithExclude(patterns ...string) Option {
+	return func(config *Config) {
+		config.Exclude = append(config.Exclude, patterns...)
+	}
+}
+
+func WithMaxDepth(depth int) Option {
+	return func(config *Config) {
+		config.MaxDepth = depth
+	}
+}
+
+func WithStrict() Option {
+	return func(config *Config) {
+		config.Strict = true
+	}
+}
+
+// 文件是否会被go build选中，包括文件名中的GOOS/GOARCH以及//go:build约束
+func (c *Config) MatchFile(file string) bool {
+	match, err := c.BuildContext.MatchFile(filepath.Dir(file), filepath.Base(file))
+	if err != nil {
+		Log.Sugar().Warnf("can't match build constraints of file:%s, error:%s", file, err.Error())
+		return false
+	}
+	return match
+}
+
+func (c *Config) describeBuildContext() string {
+	describe := fmt.Sprintf("GOOS=%s GOARCH=%s", c.BuildContext.GOOS, c.BuildContext.GOARCH)
+	if len(c.BuildContext.BuildTags) > 0 {
+		describe += " tags=" + strings.Join(c.BuildContext.BuildTags, ",")
+	}
+	return describe
+}
@@ -0,0 +1,216 @@
+package fileparser
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 求调用图以及结构体包含关系中的强连通分量
+func (n *NodeManager) mergeCycles() {
+	identities := make([]string, 0)
+	callEdges := make(map[string][]string, 0)
+	for _, nodes := range n.allFunctions {
+		for _, node := range nodes {
+			identities = append(identities, node.getIdentity())
+			callEdges[node.getIdentity()] = n.getCallTargets(node)
+		}
+	}
+	sort.Strings(identities)
+	for i, cycle := range getCycles(identities, callEdges) {
+		for _, identity := range cycle {
+			n.callCycleOf[identity] = i + 1
+		}
+	}
+
+	// 结构体以及具名类型之间按值的包含关系，经过指针、切片等容器的引用不构成环
+	identities = make([]string, 0)
+	typeEdges := make(map[string][]string, 0)
+	addRelations := func(identity string, relations []*fieldRelation) {
+		identities = append(identities, identity)
+		for _, relation := range relations {
+			if (relation.structNode != nil || relation.typeNode != nil) && relation.isValueContainment() {
+				typeEdges[identity] = append(typeEdges[identity], relation.getIdentity())
+			}
+		}
+	}
+	for identity, structNode := range n.allStructs {
+		addRelations(identity, structNode.fieldRelations)
+	}
+	for identity, typeNode := range n.allTypes {
+		addRelations(identity, typeNode.typeRelations)
+	}
+	sort.Strings(identities)
+	for i, cycle := range getCycles(identities, typeEdges) {
+		for _, identity := range cycle {
+			n.typeCycleOf[identity] = i + 1
+		}
+	}
+}
+
+// 函数调用的函数，接口方法调用到它的全部实现
+func (n *NodeManager) getCallTargets(node *FunctionNode) []string {
+	targets := make([]string, 0)
+	for identity := range node.callee {
+		targets = append(targets, identity)
+	}
+	for _, method := range n.getImplementMethods(node) {
+		targets = append(targets, method.getIdentity())
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// 两个节点是否在同一个环中
+func isSameCycle(cycleOf map[string]int, from string, to string) bool {
+	return cycleOf[from] > 0 && cycleOf[from] == cycleOf[to]
+}
+
+// 按分量序号归类，转换成展示用的名称
+func getCycleNames(cycleOf map[string]int, getName func(string) string) [][]string {
+	components := make(map[int][]string, 0)
+	for identity, index := range cycleOf {
+		components[index] = append(components[index], getName(identity))
+	}
+	result := make([][]string, 0, len(components))
+	for _, names := range components {
+		sort.Strings(names)
+		result = append(result, names)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i][0] < result[j][0]
+	})
+	return result
+}
+
+// 递归以及相互递归的函数，每一组是一个强连通分量
+func (n *NodeManager) GetCallCycles() [][]string {
+	functionNodes := make(map[string]*FunctionNode, 0)
+	for _, nodes := range n.allFunctions {
+		for _, node := range nodes {
+			functionNodes[node.getIdentity()] = node
+		}
+	}
+	return getCycleNames(n.callCycleOf, func(identity string) string {
+		return functionNodes[identity].getDisplayName()
+	})
+}
+
+// 按值相互包含的结构体以及具名类型，每一组是一个强连通分量，经过指针、切片等容器的引用不算
+func (n *NodeManager) GetStructCycles() [][]string {
+	return getCycleNames(n.typeCycleOf, func(identity string) string {
+		if structNode, ok := n.allStructs[identity]; ok {
+			return structNode.getDisplayName()
+		}
+		return n.allTypes[identity].getDisplayName()
+	})
+}
+
+// 包之间的调用关系，value为调用关系的数量，接口分派也算作调用
+func (n *NodeManager) getPackageCalls() map[string]map[string]int {
+	calls := make(map[string]map[string]int, 0)
+	for _, nodes := range n.allFunctions {
+		for _, node := range nodes {
+			from := node.fileNode.packagePath
+			if _, ok := calls[from]; !ok {
+				calls[from] = make(map[string]int, 0)
+			}
+			targets := make([]*FunctionNode, 0)
+			for _, callee := range node.callee {
+				targets = append(targets, callee)
+			}
+			targets = append(targets, n.getImplementMethods(node)...)
+			for _, target := range targets {
+				if to := target.fileNode.packagePath; to != from {
+					calls[from][to]++
+				}
+			}
+		}
+	}
+	return calls
+}
+
+func (n *NodeManager) getPackageCallCycles(calls map[string]map[string]int) [][]string {
+	packages := make([]string, 0)
+	edges := make(map[string][]string, 0)
+	for from, targets := range calls {
+		packages = append(packages, from)
+		for to := range targets {
+			edges[from] = append(edges[from], to)
+		}
+		sort.Strings(edges[from])
+	}
+	sort.Strings(packages)
+	return getCycles(packages, edges)
+}
+
+// 调用关系上相互依赖的包，导入关系不允许有环，但是通过接口以及回调仍然可能形成环
+func (n *NodeManager) GetPackageCallCycles() [][]string {
+	packageNames := make(map[string]string, 0)
+	for packagePath, fileNodes := range n.packages {
+		if len(fileNodes) > 0 {
+			packageNames[packagePath] = fileNodes[0].getPackageDisplayName()
+		}
+	}
+
+	result := make([][]string, 0)
+	for _, cycle := range n.getPackageCallCycles(n.getPackageCalls()) {
+		names := make([]string, 0, len(cycle))
+		for _, packagePath := range cycle {
+			names = append(names, packageNames[packagePath])
+		}
+		sort.Strings(names)
+		result = append(result, names)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i][0] < result[j][0]
+	})
+	return result
+}
+
+// 包之间的调用关系图，边的粗细表示调用关系的数量，调用环标红
+func (n *NodeManager) DrawPackageCalls() {
+	calls := n.getPackageCalls()
+
+	cycleOf := make(map[string]int, 0)
+	for i, cycle := range n.getPackageCallCycles(calls) {
+		for _, packagePath := range cycle {
+			cycleOf[packagePath] = i + 1
+		}
+	}
+
+	packages := make([]string, 0)
+	for packagePath := range calls {
+		packages = append(packages, packagePath)
+	}
+	sort.Strings(packages)
+
+	content := n.newGraph()
+	for _, packagePath := range packages {
+		attributes := fmt.Sprintf("label=\"%s\\nfiles: %d\", shape=\"box\"", packagePath, len(n.packages[packagePath]))
+		if cycleOf[packagePath] > 0 {
+			attributes += ", color=\"red\""
+		}
+		content.WriteString(fmt.Sprintf("\"%s\" [%s];\n", packagePath, attributes))
+	}
+
+	for _, packagePath := range packages {
+		targets := make([]string, 0)
+		for target := range calls[packagePath] {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+
+		for _, target := range targets {
+			count := calls[packagePath][target]
+			attributes := fmt.Sprintf("label=\"%d\", penwidth=\"%.1f\"", count, 1+math.Log2(float64(count)))
+			if isSameCycle(cycleOf, packagePath, target) {
+				attributes += ", color=\"red\""
+			}
+			content.WriteString(fmt.Sprintf("\"%s\" -> \"%s\" [%s];\n", packagePath, target, attributes))
+		}
+	}
+	content.WriteString("}")
+
+	n.writeGraph("package_calls", content)
+}
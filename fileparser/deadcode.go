@@ -0,0 +1,340 @@
+package fileparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+)
+
+// 死代码分析的入口
+type DeadCodeRoot string
+
+const (
+	// main包的main函数
+	RootMain DeadCodeRoot = "main"
+	// init函数以及包级别变量初始化时用到的函数
+	RootInit DeadCodeRoot = "init"
+	// 导出的函数以及导出类型上导出的方法
+	RootExported DeadCodeRoot = "exported"
+	// Test、Benchmark、Example以及Fuzz函数，需要同时开启WithTests
+	RootTests DeadCodeRoot = "tests"
+	// 没有直接调用而是作为值使用的函数，比如注册的路由以及回调
+	RootHandlers DeadCodeRoot = "handlers"
+)
+
+// 默认不把导出的API作为入口，库项目可以通过WithDeadCodeRoots加上RootExported
+var defaultDeadCodeRoots = []DeadCodeRoot{RootMain, RootInit, RootTests, RootHandlers}
+
+// 死代码报告的格式
+type ReportFormat string
+
+const (
+	FormatText ReportFormat = "text"
+	FormatJSON ReportFormat = "json"
+)
+
+// 指定死代码分析的入口，替换默认的入口
+func WithDeadCodeRoots(roots ...DeadCodeRoot) Option {
+	return func(config *Config) {
+		config.DeadCodeRoots = append([]DeadCodeRoot{}, roots...)
+	}
+}
+
+func (c *Config) hasDeadCodeRoot(root DeadCodeRoot) bool {
+	for _, r := range c.DeadCodeRoots {
+		if r == root {
+			return true
+		}
+	}
+	return false
+}
+
+type jsonDeadFunction struct {
+	Identity string        `json:"identity"`
+	Name     string        `json:"name"`
+	Exported bool          `json:"exported"`
+	Position *jsonPosition `json:"position,omitempty"`
+}
+
+type jsonDeadPackage struct {
+	Package   string              `json:"package"`
+	Functions []*jsonDeadFunction `json:"functions"`
+}
+
+type jsonDeadCode struct {
+	Roots    []DeadCodeRoot     `json:"roots"`
+	Packages []*jsonDeadPackage `json:"packages"`
+}
+
+// 从入口出发无法到达的函数以及方法，按包归类，map[package][]function
+func (n *NodeManager) GetDeadFunctions() map[string][]string {
+	result := make(map[string][]string, 0)
+	for _, node := range n.getDeadFunctions() {
+		packageName := node.fileNode.getPackageDisplayName()
+		result[packageName] = append(result[packageName], node.getDisplayName())
+	}
+	return result
+}
+
+// 按包输出死代码报告，text格式每个包一段，json格式包含函数的位置
+func (n *NodeManager) ExportDeadCode(w io.Writer, format ReportFormat) error {
+	packages := make([]*jsonDeadPackage, 0)
+	packageIndex := make(map[string]*jsonDeadPackage, 0)
+	for _, node := range n.getDeadFunctions() {
+		packagePath := node.fileNode.packagePath
+		if _, ok := packageIndex[packagePath]; !ok {
+			packageIndex[packagePath] = &jsonDeadPackage{Package: packagePath, Functions: make([]*jsonDeadFunction, 0)}
+			packages = append(packages, packageIndex[packagePath])
+		}
+		packageIndex[packagePath].Functions = append(packageIndex[packagePath].Functions, &jsonDeadFunction{
+			Identity: trimQuote(node.getIdentity()),
+			Name:     trimQuote(node.getDisplayName()),
+			Exported: node.isExported(),
+			Position: newJSONPosition(node.position),
+		})
+	}
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(&jsonDeadCode{Roots: n.config.DeadCodeRoots, Packages: packages})
+	case FormatText:
+		for _, deadPackage := range packages {
+			if _, err := fmt.Fprintf(w, "%s (%d)\n", deadPackage.Package, len(deadPackage.Functions)); err != nil {
+				return err
+			}
+			for _, function := range deadPackage.Functions {
+				position := ""
+				if function.Position != nil {
+					position = fmt.Sprintf("%s:%d:%d", function.Position.File, function.Position.Line, function.Position.Column)
+				}
+				if _, err := fmt.Fprintf(w, "\t%s\t%s\n", function.Name, position); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown report format:%s", format)
+}
+
+// 无法到达的函数，按包路径以及identity排序，函数字面量和接口中声明的方法不算
+func (n *NodeManager) getDeadFunctions() []*FunctionNode {
+	reachable := n.getReachable(n.getDeadCodeRoots())
+
+	result := make([]*FunctionNode, 0)
+	for _, nodes := range n.allFunctions {
+		for _, node := range nodes {
+			if reachable[node] || node.literal != nil || node.isInterfaceMethod() {
+				continue
+			}
+			// 没有把测试作为入口时，测试代码都是不可达的
+			if node.fileNode.isTest && !n.config.hasDeadCodeRoot(RootTests) {
+				continue
+			}
+			result = append(result, node)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].fileNode.packagePath != result[j].fileNode.packagePath {
+			return result[i].fileNode.packagePath < result[j].fileNode.packagePath
+		}
+		return result[i].getIdentity() < result[j].getIdentity()
+	})
+	return result
+}
+
+// 导出的函数，方法要求接收者的类型也是导出的
+func (s *FunctionNode) isExported() bool {
+	if s.literal != nil || !ast.IsExported(s.name) {
+		return false
+	}
+	return s.receiver == "" || ast.IsExported(s.receiver)
+}
+
+func (n *NodeManager) getDeadCodeRoots() []*FunctionNode {
+	handlers, initializers := n.getFunctionValues()
+
+	roots := make([]*FunctionNode, 0)
+	for _, nodes := range n.allFunctions {
+		for _, node := range nodes {
+			if node.literal != nil || node.isInterfaceMethod() {
+				continue
+			}
+			isRoot := false
+			switch {
+			case node.receiver == "" && node.name == "main" && node.fileNode.packageName == "main":
+				isRoot = n.config.hasDeadCodeRoot(RootMain)
+			case node.receiver == "" && node.name == "init" || initializers[node]:
+				isRoot = n.config.hasDeadCodeRoot(RootInit)
+			}
+			if n.config.hasDeadCodeRoot(RootExported) && node.isExported() && !node.fileNode.isTest && node.fileNode.packageName != "main" {
+				isRoot = true
+			}
+			if n.config.hasDeadCodeRoot(RootTests) && (node.isTestEntry() || node.isTestMain()) {
+				isRoot = true
+			}
+			if n.config.hasDeadCodeRoot(RootHandlers) && handlers[node] {
+				isRoot = true
+			}
+			if isRoot {
+				roots = append(roots, node)
+			}
+		}
+	}
+	return append(roots, n.getExternalDispatchMethods()...)
+}
+
+// 没有出现在调用位置的函数引用，比如注册的路由以及回调，
+// 第二个返回值是包级别变量初始化时用到的函数，这些函数在init阶段就会执行
+func (n *NodeManager) getFunctionValues() (map[*FunctionNode]bool, map[*FunctionNode]bool) {
+	handlers := make(map[*FunctionNode]bool, 0)
+	initializers := make(map[*FunctionNode]bool, 0)
+	for _, fileNodes := range n.packages {
+		for _, fileNode := range fileNodes {
+			if fileNode.astFile == nil {
+				continue
+			}
+			// 被调用的函数名，剩下的函数引用都是作为值使用的
+			called := make(map[*ast.Ident]bool, 0)
+			ast.Inspect(fileNode.astFile, func(node ast.Node) bool {
+				if call, ok := node.(*ast.CallExpr); ok {
+					if ident := getCalledIdent(call); ident != nil {
+						called[ident] = true
+					}
+				}
+				return true
+			})
+			ast.Inspect(fileNode.astFile, func(node ast.Node) bool {
+				if ident, ok := node.(*ast.Ident); ok && !called[ident] {
+					if functionNode := n.getReferencedFunction(ident); functionNode != nil {
+						handlers[functionNode] = true
+					}
+				}
+				return true
+			})
+
+			for _, decl := range fileNode.astFile.Decls {
+				genDecl, ok := decl.(*ast.GenDecl)
+				if !ok || genDecl.Tok != token.VAR {
+					continue
+				}
+				ast.Inspect(genDecl, func(node ast.Node) bool {
+					if ident, ok := node.(*ast.Ident); ok {
+						if functionNode := n.getReferencedFunction(ident); functionNode != nil {
+							initializers[functionNode] = true
+						}
+					}
+					return true
+				})
+			}
+		}
+	}
+	return handlers, initializers
+}
+
+func getCalledIdent(call *ast.CallExpr) *ast.Ident {
+	fun := ast.Unparen(call.Fun)
+	switch x := fun.(type) {
+	case *ast.IndexExpr:
+		fun = x.X
+	case *ast.IndexListExpr:
+		fun = x.X
+	}
+	switch x := fun.(type) {
+	case *ast.Ident:
+		return x
+	case *ast.SelectorExpr:
+		return x.Sel
+	}
+	return nil
+}
+
+// 项目外的接口可能在项目外被调用，比如fmt对Stringer的判断，实现这些接口的方法都认为是可达的
+func (n *NodeManager) getExternalDispatchMethods() []*FunctionNode {
+	interfaces := n.getExternalInterfaces()
+
+	objects := make([]*types.TypeName, 0)
+	for _, structNode := range n.allStructs {
+		objects = append(objects, structNode.object)
+	}
+	for _, typeNode := range n.allTypes {
+		if !typeNode.alias {
+			objects = append(objects, typeNode.object)
+		}
+	}
+
+	result := make([]*FunctionNode, 0)
+	for _, object := range objects {
+		for _, iface := range interfaces {
+			if implemented, _ := implementsInterface(object, iface); !implemented {
+				continue
+			}
+			methodSet := types.NewMethodSet(types.NewPointer(object.Type()))
+			for i := 0; i < iface.NumMethods(); i++ {
+				selection := methodSet.Lookup(iface.Method(i).Pkg(), iface.Method(i).Name())
+				if selection == nil {
+					continue
+				}
+				if function, ok := selection.Obj().(*types.Func); ok {
+					if node, ok := n.functionObjects[function.Origin()]; ok {
+						result = append(result, node)
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// 项目中用到的项目外的接口，包括直接引用的接口，以及调用项目外的函数时参数中的接口
+func (n *NodeManager) getExternalInterfaces() []*types.Interface {
+	result := make([]*types.Interface, 0)
+	record := make(map[types.Type]bool, 0)
+	add := func(t types.Type) {
+		if record[t] {
+			return
+		}
+		record[t] = true
+		if iface, ok := t.Underlying().(*types.Interface); ok && iface.NumMethods() > 0 && iface.IsMethodSet() {
+			result = append(result, iface)
+		}
+	}
+
+	add(types.Universe.Lookup("error").Type())
+	// fmt通过类型断言调用的String方法
+	add(types.NewInterfaceType([]*types.Func{
+		types.NewFunc(token.NoPos, nil, "String", types.NewSignatureType(nil, nil, nil, nil, types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String])), false)),
+	}, nil).Complete())
+
+	for _, object := range n.info.Uses {
+		if object == nil || object.Pkg() == nil {
+			continue
+		}
+		if _, ok := n.packages[object.Pkg().Path()]; ok {
+			continue
+		}
+		switch x := object.(type) {
+		case *types.TypeName:
+			add(x.Type())
+		case *types.Func:
+			signature, ok := x.Type().(*types.Signature)
+			if !ok {
+				continue
+			}
+			for i := 0; i < signature.Params().Len(); i++ {
+				t := signature.Params().At(i).Type()
+				if slice, ok := t.(*types.Slice); ok && signature.Variadic() && i == signature.Params().Len()-1 {
+					t = slice.Elem()
+				}
+				add(t)
+			}
+		}
+	}
+	return result
+}
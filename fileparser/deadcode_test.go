@@ -0,0 +1,302 @@
+package fileparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetDeadFunctions(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		options []Option
+		want    map[string][]string
+	}{
+		{
+			name: "main、init、包级别变量初始化以及作为值使用的函数",
+			files: map[string]string{
+				"go.mod": "module example.com/app\n",
+				"main.go": `package main
+
+var table = map[string]func(){"x": fromTable}
+
+func init() { setup() }
+
+func setup() {}
+
+func fromTable() {}
+
+func register(f func()) { f() }
+
+func handler() {}
+
+func main() { register(handler) }
+
+func orphan() { orphanCallee() }
+
+func orphanCallee() {}
+
+func Exported() {}
+`,
+			},
+			want: map[string][]string{
+				"main": {`"main//Exported"`, `"main//orphan"`, `"main//orphanCallee"`},
+			},
+		},
+		{
+			name: "导出的函数以及导出类型的导出方法",
+			files: map[string]string{
+				"go.mod": "module example.com/lib\n",
+				"lib/lib.go": `package lib
+
+type Client struct{}
+
+func (c *Client) Get() { c.decode() }
+
+func (c *Client) decode() {}
+
+func (c *Client) unused() {}
+
+type inner struct{}
+
+func (inner) Public() {}
+
+func New() *Client { return build() }
+
+func build() *Client { return &Client{} }
+
+func helper() {}
+`,
+			},
+			options: []Option{WithDeadCodeRoots(RootExported)},
+			want: map[string][]string{
+				"lib": {`"lib/Client/unused"`, `"lib//helper"`, `"lib/inner/Public"`},
+			},
+		},
+		{
+			name: "默认不把导出的函数作为入口",
+			files: map[string]string{
+				"go.mod": "module example.com/lib\n",
+				"lib/lib.go": `package lib
+
+func New() { build() }
+
+func build() {}
+`,
+			},
+			want: map[string][]string{
+				"lib": {`"lib//New"`, `"lib//build"`},
+			},
+		},
+		{
+			name: "测试函数作为入口",
+			files: map[string]string{
+				"go.mod": "module example.com/lib\n",
+				"lib/lib.go": `package lib
+
+func tested() {}
+
+func untested() {}
+`,
+				"lib/lib_test.go": `package lib
+
+import "testing"
+
+func TestTested(t *testing.T) { tested() }
+
+func BenchmarkTested(b *testing.B) { tested() }
+
+func unusedHelper() {}
+`,
+			},
+			options: []Option{WithTests()},
+			want: map[string][]string{
+				"lib": {`"lib//untested"`, `"lib//unusedHelper"`},
+			},
+		},
+		{
+			name: "只有符合go test规则的函数才是测试入口",
+			files: map[string]string{
+				"go.mod": "module example.com/lib\n",
+				"lib/lib.go": `package lib
+
+func tested() {}
+
+func helper() {}
+`,
+				"lib/lib_test.go": `package lib
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) { os.Exit(m.Run()) }
+
+func Test(t *testing.T) { tested() }
+
+func Test_lower(t *testing.T) {}
+
+func Testify(t *testing.T) { helper() }
+
+func Testdata() string { return "" }
+
+func TestWrongParam(x int) { helper() }
+
+func TestResult(t *testing.T) error { return nil }
+
+func Examples() { helper() }
+
+func Example_suffix() {}
+
+func ExampleWithParam(t *testing.T) {}
+
+func BenchmarkT(t *testing.T) {}
+
+func FuzzDecode(f *testing.F) {}
+`,
+			},
+			options: []Option{WithTests()},
+			want: map[string][]string{
+				"lib": {
+					`"lib//BenchmarkT"`, `"lib//ExampleWithParam"`, `"lib//Examples"`, `"lib//TestResult"`,
+					`"lib//TestWrongParam"`, `"lib//Testdata"`, `"lib//Testify"`, `"lib//helper"`,
+				},
+			},
+		},
+		{
+			name: "不把测试作为入口时测试代码不报告，只被测试调用的函数是死代码",
+			files: map[string]string{
+				"go.mod": "module example.com/lib\n",
+				"lib/lib.go": `package lib
+
+func tested() {}
+`,
+				"lib/lib_test.go": `package lib
+
+import "testing"
+
+func TestTested(t *testing.T) { tested() }
+`,
+			},
+			options: []Option{WithTests(), WithDeadCodeRoots(RootMain)},
+			want: map[string][]string{
+				"lib": {`"lib//tested"`},
+			},
+		},
+		{
+			name: "调用接口方法时接口的全部实现都可达，包括泛型类型",
+			files: map[string]string{
+				"go.mod": "module example.com/app\n",
+				"main.go": `package main
+
+type Store interface{ Save(v int) }
+
+type mem struct{}
+
+func (mem) Save(v int) {}
+
+type disk struct{}
+
+func (*disk) Save(v int) {}
+
+func (*disk) compact() {}
+
+type cache[T any] struct{}
+
+func (cache[T]) Save(v T) {}
+
+func use(s Store) { s.Save(1) }
+
+func main() { use(mem{}) }
+`,
+			},
+			want: map[string][]string{
+				"main": {`"main/disk/compact"`},
+			},
+		},
+		{
+			name: "项目外的接口的实现认为是可达的",
+			files: map[string]string{
+				"go.mod": "module example.com/app\n",
+				"main.go": `package main
+
+import "sort"
+
+type byName []string
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Less(i, j int) bool { return b[i] < b[j] }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+type id int
+
+func (id) String() string { return "" }
+
+func (id) Error() string { return "" }
+
+func (id) other() {}
+
+func main() { sort.Sort(byName(nil)) }
+`,
+			},
+			want: map[string][]string{
+				"main": {`"main/id/other"`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeManager := parseSource(t, tt.files, tt.options...)
+			got := nodeManager.GetDeadFunctions()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetDeadFunctions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportDeadCode(t *testing.T) {
+	nodeManager := parseSource(t, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"main.go": `package main
+
+func main() {}
+
+func Orphan() {}
+`,
+	})
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err := nodeManager.ExportDeadCode(buffer, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buffer.String()), "\n"); len(lines) != 2 || lines[0] != "example.com/app (1)" || !strings.HasPrefix(lines[1], "\tmain//Orphan\t") || !strings.HasSuffix(lines[1], "main.go:5:1") {
+		t.Errorf("text report = %q", buffer.String())
+	}
+
+	buffer.Reset()
+	if err := nodeManager.ExportDeadCode(buffer, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	report := &jsonDeadCode{}
+	if err := json.Unmarshal(buffer.Bytes(), report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Packages) != 1 || len(report.Packages[0].Functions) != 1 {
+		t.Fatalf("json report = %s", buffer.String())
+	}
+	if function := report.Packages[0].Functions[0]; function.Identity != "example.com/app:Orphan" || !function.Exported || function.Position == nil || function.Position.Line != 5 {
+		t.Errorf("json function = %+v", function)
+	}
+	if !reflect.DeepEqual(report.Roots, defaultDeadCodeRoots) {
+		t.Errorf("json roots = %v, want %v", report.Roots, defaultDeadCodeRoots)
+	}
+
+	if err := nodeManager.ExportDeadCode(buffer, ReportFormat("xml")); err == nil {
+		t.Error("ExportDeadCode(xml) = nil, want error")
+	}
+}
@@ -0,0 +1,84 @@
+package fileparser
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+	"unicode/utf8"
+)
+
+// 图中展示的注释摘要的最大长度
+const maxSummaryLength = 80
+
+func WithDocSummary(enabled bool) Option {
+	return func(config *Config) {
+		config.DocSummary = enabled
+	}
+}
+
+// 类型声明的注释，只有一个类型的声明注释写在type关键字上
+func getTypeDocs(f *ast.File) map[*ast.TypeSpec]string {
+	docs := make(map[*ast.TypeSpec]string, 0)
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			doc := typeSpec.Doc
+			if doc == nil && !genDecl.Lparen.IsValid() {
+				doc = genDecl.Doc
+			}
+			docs[typeSpec] = doc.Text()
+		}
+	}
+	return docs
+}
+
+// 注释的第一句话
+func getSummary(doc string) string {
+	paragraph := doc
+	if index := strings.Index(paragraph, "\n\n"); index != -1 {
+		paragraph = paragraph[:index]
+	}
+	summary := strings.Join(strings.Fields(paragraph), " ")
+	for _, end := range []string{". ", "。"} {
+		if index := strings.Index(summary, end); index != -1 {
+			summary = summary[:index+len(strings.TrimSpace(end))]
+		}
+	}
+	if utf8.RuneCountInString(summary) > maxSummaryLength {
+		summary = string([]rune(summary)[:maxSummaryLength]) + "..."
+	}
+	return summary
+}
+
+// 节点label中的注释摘要，关闭或者没有注释时为空
+func (c *Config) getDocLabel(doc string) string {
+	if !c.DocSummary {
+		return ""
+	}
+	summary := getSummary(doc)
+	if summary == "" {
+		return ""
+	}
+	summary = strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(summary)
+	return "\\ndoc: " + summary + "\\l"
+}
+
+// 代码片段中还原成行注释
+func formatDocComment(doc string) string {
+	doc = strings.TrimRight(doc, "\n")
+	if doc == "" {
+		return ""
+	}
+	lines := strings.Split(doc, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+line, " ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,56 @@
+package fileparser
+
+import (
+	"fmt"
+	"go/scanner"
+	"strings"
+)
+
+// 单个文件中的解析错误
+type ParseError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// 多个文件的解析错误
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	switch len(e) {
+	case 0:
+		return "no errors"
+	case 1:
+		return e[0].Error()
+	}
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d errors:\n%s", len(e), strings.Join(messages, "\n"))
+}
+
+// 把go/parser返回的错误转换为带位置的错误
+func newParseErrors(file string, err error) ParseErrors {
+	result := make(ParseErrors, 0)
+	if list, ok := err.(scanner.ErrorList); ok {
+		for _, e := range list {
+			result = append(result, &ParseError{
+				File:    file,
+				Line:    e.Pos.Line,
+				Column:  e.Pos.Column,
+				Message: e.Msg,
+			})
+		}
+		return result
+	}
+	return append(result, &ParseError{File: file, Message: err.Error()})
+}
@@ -0,0 +1,88 @@
+package fileparser
+
+import (
+	"encoding/json"
+	"go/token"
+	"io"
+	"strings"
+)
+
+type jsonPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+type jsonNode struct {
+	Kind     string        `json:"kind"`
+	Identity string        `json:"identity"`
+	Name     string        `json:"name"`
+	Package  string        `json:"package"`
+	Position *jsonPosition `json:"position,omitempty"`
+	Doc      string        `json:"doc,omitempty"`
+}
+
+type jsonEdge struct {
+	Kind  string `json:"kind"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"`
+	// 字段关系经过的容器，比如 pointer, map value
+	Containers []string        `json:"containers,omitempty"`
+	Positions  []*jsonPosition `json:"positions,omitempty"`
+}
+
+type jsonGraph struct {
+	Nodes []*jsonNode `json:"nodes"`
+	Edges []*jsonEdge `json:"edges"`
+}
+
+func newJSONPosition(position token.Position) *jsonPosition {
+	if !position.IsValid() {
+		return nil
+	}
+	return &jsonPosition{File: position.Filename, Line: position.Line, Column: position.Column}
+}
+
+func trimQuote(name string) string {
+	return strings.Trim(name, "\"")
+}
+
+// 以json格式导出全部节点以及关系，包含声明以及调用点的位置
+func (n *NodeManager) ExportJSON(w io.Writer) error {
+	graph := &jsonGraph{
+		Nodes: make([]*jsonNode, 0),
+		Edges: make([]*jsonEdge, 0),
+	}
+
+	for _, node := range n.Graph().Nodes() {
+		graph.Nodes = append(graph.Nodes, &jsonNode{
+			Kind:     string(node.Kind),
+			Identity: node.ID,
+			Name:     node.Name,
+			Package:  node.Package,
+			Position: newJSONPosition(node.Position),
+			Doc:      node.Doc,
+		})
+	}
+
+	for _, edge := range n.Graph().Edges() {
+		jsonEdge := &jsonEdge{
+			Kind:       string(edge.Kind),
+			From:       edge.From,
+			To:         edge.To,
+			Label:      edge.Label,
+			Containers: edge.Containers,
+		}
+		for _, position := range edge.Positions {
+			if jsonPosition := newJSONPosition(position); jsonPosition != nil {
+				jsonEdge.Positions = append(jsonEdge.Positions, jsonPosition)
+			}
+		}
+		graph.Edges = append(graph.Edges, jsonEdge)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(graph)
+}
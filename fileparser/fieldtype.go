@@ -0,0 +1,197 @@
+package fileparser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// 字段类型中经过的容器
+const (
+	containerPointer    = "pointer"
+	containerSlice      = "slice"
+	containerArray      = "array"
+	containerMapKey     = "map key"
+	containerMapValue   = "map value"
+	containerChan       = "chan"
+	containerSendChan   = "chan<-"
+	containerRecvChan   = "<-chan"
+	containerFuncParam  = "func param"
+	containerFuncResult = "func result"
+	containerTypeArg    = "type arg"
+	containerConstraint = "constraint"
+)
+
+// 字段引用的结构体、接口或者具名类型，containers为从外到内经过的容器
+type fieldRelation struct {
+	field         string
+	containers    []string
+	structNode    *StructNode
+	interfaceNode *InterfaceNode
+	typeNode      *TypeNode
+}
+
+// 类型对象对应项目中的节点时返回关系，否则返回nil
+func (n *NodeManager) newFieldRelation(object *types.TypeName, field string, containers []string) *fieldRelation {
+	relation := &fieldRelation{field: field, containers: containers}
+	if node, ok := n.structObjects[object]; ok {
+		relation.structNode = node
+	} else if node, ok := n.interfaceObjects[object]; ok {
+		relation.interfaceNode = node
+	} else if node, ok := n.typeObjects[object]; ok {
+		relation.typeNode = node
+	} else {
+		return nil
+	}
+	return relation
+}
+
+func (r *fieldRelation) getIdentity() string {
+	if r.structNode != nil {
+		return r.structNode.getIdentity()
+	}
+	if r.typeNode != nil {
+		return r.typeNode.getIdentity()
+	}
+	return r.interfaceNode.getIdentity()
+}
+
+// 按值包含引用的类型，只经过数组，指针、切片、map、chan以及函数都会打断包含关系
+func (r *fieldRelation) isValueContainment() bool {
+	for _, container := range r.containers {
+		if container != containerArray {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *fieldRelation) getLabel() string {
+	return strings.Join(r.containers, ", ")
+}
+
+func (r *fieldRelation) getAttributes() string {
+	if len(r.containers) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [label=\"%s\"]", r.getLabel())
+}
+
+func appendContainer(containers []string, container string) []string {
+	result := make([]string, 0, len(containers)+1)
+	result = append(result, containers...)
+	return append(result, container)
+}
+
+// 遍历类型表达式，对引用到的每个具名类型回调，同时给出经过的容器
+func (n *NodeManager) walkTypeExpr(expr ast.Expr, containers []string, visit func(*types.TypeName, []string)) {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		if object, ok := n.info.Uses[x].(*types.TypeName); ok {
+			visit(object, containers)
+		}
+	case *ast.SelectorExpr:
+		if object, ok := n.info.Uses[x.Sel].(*types.TypeName); ok {
+			visit(object, containers)
+		}
+	case *ast.ParenExpr:
+		n.walkTypeExpr(x.X, containers, visit)
+	case *ast.StarExpr:
+		n.walkTypeExpr(x.X, appendContainer(containers, containerPointer), visit)
+	case *ast.ArrayType:
+		if x.Len == nil {
+			n.walkTypeExpr(x.Elt, appendContainer(containers, containerSlice), visit)
+		} else {
+			n.walkTypeExpr(x.Elt, appendContainer(containers, containerArray), visit)
+		}
+	case *ast.Ellipsis:
+		n.walkTypeExpr(x.Elt, appendContainer(containers, containerSlice), visit)
+	case *ast.MapType:
+		n.walkTypeExpr(x.Key, appendContainer(containers, containerMapKey), visit)
+		n.walkTypeExpr(x.Value, appendContainer(containers, containerMapValue), visit)
+	case *ast.ChanType:
+		switch x.Dir {
+		case ast.SEND:
+			n.walkTypeExpr(x.Value, appendContainer(containers, containerSendChan), visit)
+		case ast.RECV:
+			n.walkTypeExpr(x.Value, appendContainer(containers, containerRecvChan), visit)
+		default:
+			n.walkTypeExpr(x.Value, appendContainer(containers, containerChan), visit)
+		}
+	case *ast.FuncType:
+		n.walkFieldList(x.Params, appendContainer(containers, containerFuncParam), visit)
+		n.walkFieldList(x.Results, appendContainer(containers, containerFuncResult), visit)
+	case *ast.IndexExpr:
+		n.walkTypeExpr(x.X, containers, visit)
+		n.walkTypeExpr(x.Index, appendContainer(containers, containerTypeArg), visit)
+	case *ast.IndexListExpr:
+		n.walkTypeExpr(x.X, containers, visit)
+		for _, index := range x.Indices {
+			n.walkTypeExpr(index, appendContainer(containers, containerTypeArg), visit)
+		}
+	case *ast.StructType:
+		n.walkFieldList(x.Fields, containers, visit)
+	case *ast.InterfaceType:
+		n.walkFieldList(x.Methods, containers, visit)
+	case *ast.BinaryExpr, *ast.UnaryExpr:
+		// 类型约束中的并集以及~T
+		for _, term := range getTypeTerms(x) {
+			if term != expr {
+				n.walkTypeExpr(term, containers, visit)
+			}
+		}
+	}
+}
+
+func (n *NodeManager) walkFieldList(list *ast.FieldList, containers []string, visit func(*types.TypeName, []string)) {
+	if list == nil {
+		return
+	}
+	for _, field := range list.List {
+		n.walkTypeExpr(field.Type, containers, visit)
+	}
+}
+
+// 和walkTypeExpr一样，用于没有类型表达式的情况，比如类型推导出的全局变量
+func walkType(t types.Type, containers []string, visit func(*types.TypeName, []string)) {
+	switch x := t.(type) {
+	case *types.Named:
+		visit(x.Origin().Obj(), containers)
+		for i := 0; i < x.TypeArgs().Len(); i++ {
+			walkType(x.TypeArgs().At(i), appendContainer(containers, containerTypeArg), visit)
+		}
+	case *types.Alias:
+		// 别名本身也是节点，由别名指向它代表的类型
+		visit(x.Obj(), containers)
+	case *types.Pointer:
+		walkType(x.Elem(), appendContainer(containers, containerPointer), visit)
+	case *types.Slice:
+		walkType(x.Elem(), appendContainer(containers, containerSlice), visit)
+	case *types.Array:
+		walkType(x.Elem(), appendContainer(containers, containerArray), visit)
+	case *types.Map:
+		walkType(x.Key(), appendContainer(containers, containerMapKey), visit)
+		walkType(x.Elem(), appendContainer(containers, containerMapValue), visit)
+	case *types.Chan:
+		switch x.Dir() {
+		case types.SendOnly:
+			walkType(x.Elem(), appendContainer(containers, containerSendChan), visit)
+		case types.RecvOnly:
+			walkType(x.Elem(), appendContainer(containers, containerRecvChan), visit)
+		default:
+			walkType(x.Elem(), appendContainer(containers, containerChan), visit)
+		}
+	case *types.Signature:
+		for i := 0; i < x.Params().Len(); i++ {
+			walkType(x.Params().At(i).Type(), appendContainer(containers, containerFuncParam), visit)
+		}
+		for i := 0; i < x.Results().Len(); i++ {
+			walkType(x.Results().At(i).Type(), appendContainer(containers, containerFuncResult), visit)
+		}
+	case *types.Struct:
+		for i := 0; i < x.NumFields(); i++ {
+			walkType(x.Field(i).Type(), containers, visit)
+		}
+	}
+}
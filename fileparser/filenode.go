@@ -2,6 +2,7 @@ package fileparser
 
 import (
 	"bytes"
+	"go/ast"
 	"path/filepath"
 	"strings"
 )
@@ -13,10 +14,17 @@ type FileNode struct {
 	fileNodeName    string
 	fileNodeTagName string
 	packageName     string
+	packagePath     string
+	isTest          bool
+	astFile         *ast.File
 	structNodes     map[string]*StructNode
 	interfaceNodes  map[string]*InterfaceNode
+	typeNodes       map[string]*TypeNode
 	functionNodes   map[string][]*FunctionNode
-	importers       map[string]string
+	globalNodes     map[string]*GlobalNode
+	// 包名到导入路径，点导入的包单独记录
+	importers  map[string]string
+	dotImports []string
 }
 
 func (f *FileNode) MergeFunction() {
@@ -28,9 +36,26 @@ func (f *FileNode) MergeFunction() {
 	}
 }
 
-func (f *FileNode) MergeStruct(structTypes map[string]map[string]*StructNode, interfaceNames map[string]map[string]*InterfaceNode) {
+// 展示用的包名，只有短包名冲突时才使用完整的导入路径
+func (f *FileNode) getPackageDisplayName() string {
+	if len(f.nodeManager.packageNames[f.packageName]) > 1 {
+		return f.packagePath
+	}
+	return f.packageName
+}
+
+// 包中声明的identity，导入路径中不会出现冒号，用冒号分隔导入路径和包内的名称，
+// 避免 a/b 包中的 X 和 a 包中 b 的方法 X 这类名称冲突
+func (f *FileNode) getIdentity(name string) string {
+	return "\"" + f.packagePath + ":" + name + "\""
+}
+
+func (f *FileNode) MergeStruct() {
 	for _, structNode := range f.structNodes {
-		structNode.Merge(structTypes, interfaceNames)
+		structNode.Merge()
+	}
+	for _, typeNode := range f.typeNodes {
+		typeNode.Merge()
 	}
 }
 
@@ -41,10 +66,14 @@ func NewFileNode(nodeManager *NodeManager, file string, packageName string) *Fil
 		file:            file,
 		fileNodeName:    strings.ReplaceAll(filepath.Base(file), ".", "_"),
 		fileNodeTagName: filepath.Base(file),
+		isTest:          strings.HasSuffix(file, "_test.go"),
 		structNodes:     make(map[string]*StructNode, 0),
 		interfaceNodes:  make(map[string]*InterfaceNode, 0),
+		typeNodes:       make(map[string]*TypeNode, 0),
 		functionNodes:   make(map[string][]*FunctionNode, 0),
+		globalNodes:     make(map[string]*GlobalNode, 0),
 		importers:       make(map[string]string, 0),
+		dotImports:      make([]string, 0),
 	}
 }
 
@@ -3,18 +3,101 @@ package fileparser
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// 调用的方式
+const (
+	callKindCall     = ""
+	callKindGo       = "go"
+	callKindDefer    = "defer"
+	callKindCallback = "callback"
+	callKindClosure  = "closure"
+)
+
+// 调用关系上的附加信息
+type callEdge struct {
+	caller *FunctionNode
+	callee *FunctionNode
+	kind   string
+	// 通过嵌入字段提升的方法，记录经过的嵌入字段
+	embeddedPath []string
+	// 全部调用点的位置
+	positions []token.Position
+}
+
+func (e *callEdge) getAttributes() string {
+	attributes := make([]string, 0)
+	if source := e.caller.fileNode.nodeManager.config.getSourceAttributes(e.positions...); source != "" {
+		attributes = append(attributes, source)
+	}
+	// 递归调用的边标红
+	colors := make([]string, 0)
+	if isSameCycle(e.caller.fileNode.nodeManager.callCycleOf, e.caller.getIdentity(), e.callee.getIdentity()) {
+		colors = append(colors, "red")
+	}
+	labels := make([]string, 0)
+	switch e.kind {
+	case callKindGo:
+		colors = append(colors, "blue")
+		labels = append(labels, "go")
+	case callKindDefer:
+		labels = append(labels, "defer")
+	case callKindCallback:
+		attributes = append(attributes, "style=\"dashed\"")
+		colors = append(colors, "darkgreen")
+		labels = append(labels, "callback")
+	case callKindClosure:
+		attributes = append(attributes, "style=\"dotted\"")
+		labels = append(labels, "closure")
+	}
+	if len(e.embeddedPath) > 0 {
+		if e.kind == callKindCall {
+			attributes = append(attributes, "style=\"dashed\"")
+		}
+		labels = append(labels, "via "+strings.Join(e.embeddedPath, "."))
+	}
+
+	if len(colors) > 0 {
+		attributes = append(attributes, fmt.Sprintf("color=\"%s\"", colors[0]))
+	}
+	if len(labels) > 0 {
+		attributes = append(attributes, fmt.Sprintf("label=\"%s\"", strings.Join(labels, ", ")))
+	}
+	if len(attributes) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(attributes, ", ") + "]"
+}
+
 type FunctionNode struct {
 	fileNode   *FileNode
 	name       string
 	receiver   string
 	parameters []string
 	returns    []string
+	typeParams []*typeParam
 	callee     map[string]*FunctionNode
 	caller     map[string]*FunctionNode
-	content    string
+	// key为被调用函数的identity
+	calleeEdges map[string]*callEdge
+	content     string
+	decl        *ast.FuncDecl
+	object      *types.Func
+	// 函数字面量，命名为 父函数$序号
+	literal     *ast.FuncLit
+	literalKind string
+	parent      *FunctionNode
+	children    map[*ast.FuncLit]*FunctionNode
+	position    token.Position
+	doc         string
+	// init以及_在包中可以重复声明，记录声明的位置区分它们，比如 main.go:12:1
+	site string
 }
 
 func NewFunctionNode(fileNode *FileNode, name string, receiver string, content string, params []string, returns []string) *FunctionNode {
@@ -24,14 +107,16 @@ func NewFunctionNode(fileNode *FileNode, name string, receiver string, content s
 		receiver = strings.Trim(receiver, "*")
 	}
 	return &FunctionNode{
-		fileNode:   fileNode,
-		name:       name,
-		receiver:   receiver,
-		content:    content,
-		callee:     make(map[string]*FunctionNode, 0),
-		caller:     make(map[string]*FunctionNode, 0),
-		parameters: params,
-		returns:    returns,
+		fileNode:    fileNode,
+		name:        name,
+		receiver:    receiver,
+		content:     content,
+		callee:      make(map[string]*FunctionNode, 0),
+		caller:      make(map[string]*FunctionNode, 0),
+		calleeEdges: make(map[string]*callEdge, 0),
+		children:    make(map[*ast.FuncLit]*FunctionNode, 0),
+		parameters:  params,
+		returns:     returns,
 	}
 }
 
@@ -41,19 +126,63 @@ func (s *FunctionNode) Merge() {
 		nodeManager.allFunctions[s.name] = make([]*FunctionNode, 0)
 	}
 	nodeManager.allFunctions[s.name] = append(nodeManager.allFunctions[s.name], s)
+
+	if object := s.getObject(); object != nil {
+		s.object = object
+		nodeManager.functionObjects[object] = s
+	}
+}
+
+func (s *FunctionNode) getSnippet() string {
+	return formatSnippet(s.position, s.doc, s.content)
+}
+
+// 可以重复声明的函数在名称后面带上声明的位置，比如 init@main.go:12:1
+func (s *FunctionNode) getLocalName() string {
+	if s.site == "" {
+		return s.name
+	}
+	return s.name + "@" + s.site
 }
 
 func (s *FunctionNode) getIdentity() string {
-	return "\"" + s.fileNode.packageName + "/" + s.receiver + "/" + s.name + "\""
+	if s.receiver != "" {
+		return s.fileNode.getIdentity(s.receiver + "." + s.getLocalName())
+	}
+	return s.fileNode.getIdentity(s.getLocalName())
+}
+
+func (s *FunctionNode) getDisplayName() string {
+	return "\"" + s.fileNode.getPackageDisplayName() + "/" + s.receiver + "/" + s.getLocalName() + "\""
+}
+
+// 图中展示的名称，带上类型参数
+func (s *FunctionNode) getLabel() string {
+	if s.receiver != "" {
+		return "\"" + s.fileNode.getPackageDisplayName() + "/" + s.receiver + formatTypeParams(s.typeParams) + "/" + s.getLocalName() + "\""
+	}
+	return "\"" + s.fileNode.getPackageDisplayName() + "//" + s.getLocalName() + formatTypeParams(s.typeParams) + "\""
+}
+
+// 可以在包中重复声明的函数，包括全部的init函数以及名称为_的函数和方法
+func isRepeatableFunction(decl *ast.FuncDecl) bool {
+	return decl.Name.Name == "_" || (decl.Recv == nil && decl.Name.Name == "init")
+}
+
+// init函数以及其中的函数字面量
+func (s *FunctionNode) inInit() bool {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s.receiver == "" && s.name == "init"
 }
 
 func (s *FunctionNode) GetCalleeCodeSnippet(result map[string]string) map[string]string {
-	s.deduceCallee()
-	result[s.getIdentity()] = s.content
+	result[s.getDisplayName()] = s.getSnippet()
 
 	for _, callee := range s.callee {
-		if _, ok := result[callee.getIdentity()]; ok == false {
-			result[callee.getIdentity()] = callee.content
+		if _, ok := result[callee.getDisplayName()]; ok == false {
+			result[callee.getDisplayName()] = callee.getSnippet()
 			tmp := callee.GetCalleeCodeSnippet(result)
 			for k, v := range tmp {
 				result[k] = v
@@ -65,11 +194,10 @@ func (s *FunctionNode) GetCalleeCodeSnippet(result map[string]string) map[string
 }
 
 func (s *FunctionNode) GetCallerCodeSnippet(result map[string]string) map[string]string {
-	s.deduceCaller()
-	result[s.getIdentity()] = s.content
+	result[s.getDisplayName()] = s.getSnippet()
 	for _, caller := range s.caller {
-		if _, ok := result[caller.getIdentity()]; ok == false {
-			result[caller.getIdentity()] = caller.content
+		if _, ok := result[caller.getDisplayName()]; ok == false {
+			result[caller.getDisplayName()] = caller.getSnippet()
 			tmp := caller.GetCallerCodeSnippet(result)
 			for k, v := range tmp {
 				result[k] = v
@@ -80,158 +208,291 @@ func (s *FunctionNode) GetCallerCodeSnippet(result map[string]string) map[string
 	return result
 }
 
-// 查找函数的调用者
-func (s *FunctionNode) deduceCaller() {
-	for _, nodes := range s.fileNode.nodeManager.allFunctions {
-		for _, node := range nodes {
-			if node.getIdentity() == s.getIdentity() {
-				continue
-			}
-			lines := strings.Split(node.content, "\n")
-			for _, line := range lines {
-				line = strings.TrimSpace(line)
-				// 跳过函数头，注释
-				if !strings.HasPrefix(line, "*") && !strings.HasPrefix(line, "func") && !strings.HasPrefix(line, "//") &&
-					!strings.HasPrefix(line, "/*") && (strings.Contains(line, "."+s.name+"(") || strings.Contains(line, " "+s.name+"("))  {
-					node2 := node.checkCallerInvolved(line, s)
-					if node2 != nil && node2 == s {
-						s.caller[node.getIdentity()] = node
-					}
-				}
-			}
-		}
+func (s *FunctionNode) getBody() *ast.BlockStmt {
+	if s.literal != nil {
+		return s.literal.Body
+	}
+	if s.decl != nil {
+		return s.decl.Body
 	}
+	return nil
 }
 
-func (s *FunctionNode) checkCallerInvolved(line string, node *FunctionNode) *FunctionNode{
-	if strings.Contains(line, " "+node.name+"(") {
-		if node.fileNode.packageName == s.fileNode.packageName && node.receiver == "" {
-			return node
-		}
-	} else if strings.Contains(line, "."+node.name+"(") {
-		pos := strings.Index(line, "."+node.name+"(") - 1
-		tmp := bytes.NewBuffer([]byte{})
-		for pos >= 0 && line[pos] != ' ' {
-			tmp.WriteByte(line[pos])
-			pos -= 1
+// 找出函数体中的函数字面量作为子节点，嵌套的字面量归属于外层的字面量
+func (s *FunctionNode) parseLiterals(content []byte, base token.Pos) []*FunctionNode {
+	body := s.getBody()
+	if body == nil {
+		return nil
+	}
+
+	kinds := getLiteralKinds(body)
+	literals := make([]*FunctionNode, 0)
+	ast.Inspect(body, func(n ast.Node) bool {
+		lit, ok := n.(*ast.FuncLit)
+		if !ok {
+			return true
 		}
+		name := fmt.Sprintf("%s$%d", s.getLocalName(), len(s.children)+1)
+		literal := NewFunctionNode(s.fileNode, name, s.receiver, getNodeSource(content, base, lit, 0), s.parameters, s.returns)
+		literal.literal = lit
+		literal.literalKind = kinds[lit]
+		literal.parent = s
+		literal.typeParams = s.typeParams
+		literal.position = s.fileNode.nodeManager.fset.Position(lit.Pos())
+		s.children[lit] = literal
+
+		literals = append(literals, literal)
+		literals = append(literals, literal.parseLiterals(content, base)...)
+		return false
+	})
+	return literals
+}
 
-		// 是否在模块中
-		name := tmp.String()
-		if _, ok := s.fileNode.importers[name]; ok {
-			if node.fileNode.packageName == name {
-				return node
+// 函数字面量的使用方式，go/defer启动，立即调用，作为回调参数，或者作为闭包保存下来
+func getLiteralKinds(body ast.Node) map[*ast.FuncLit]string {
+	kinds := make(map[*ast.FuncLit]string, 0)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.GoStmt:
+			if lit, ok := ast.Unparen(x.Call.Fun).(*ast.FuncLit); ok {
+				kinds[lit] = callKindGo
+			}
+		case *ast.DeferStmt:
+			if lit, ok := ast.Unparen(x.Call.Fun).(*ast.FuncLit); ok {
+				kinds[lit] = callKindDefer
 			}
-		} else {
-			if node.receiver == s.receiver {
-				return node
+		case *ast.CallExpr:
+			if lit, ok := ast.Unparen(x.Fun).(*ast.FuncLit); ok {
+				if _, ok := kinds[lit]; !ok {
+					kinds[lit] = callKindCall
+				}
+			}
+			for _, arg := range x.Args {
+				if lit, ok := ast.Unparen(arg).(*ast.FuncLit); ok {
+					kinds[lit] = callKindCallback
+				}
+			}
+		case *ast.FuncLit:
+			if _, ok := kinds[x]; !ok {
+				kinds[x] = callKindClosure
 			}
 		}
-
-		//// 优先找同一个模块里面的
-		//fmt.Println(s.fileNode.file)
-		//fmt.Println(s.getIdentity())
-		//fmt.Println(line)
-		//for i, node := range nodes {
-		//	fmt.Printf("node %d: %s\n", i , node.getIdentity())
-		//}
-		//
-		//index := -1
-		//fmt.Println("输入对应的node序号:")
-		//_, _ = fmt.Scanf("%d", &index)
-		//if index >= 0 {
-		//	s.callee[nodes[index].getIdentity()] = nodes[index]
-		//}
-		//if len(nodes) == 1 && unicode.IsLower([]rune(nodes[0].name)[0]) && index == 0{
-		//	s.fileNode.nodeManager.knownModuleFunction[nodes[0].name] = true
-		//}
-	}
-	return nil
+		return true
+	})
+	return kinds
 }
 
-func (s *FunctionNode) checkCalleeInvolved(line string, functionName string, nodes []*FunctionNode) *FunctionNode{
-	if strings.Contains(line, " "+functionName+"(") {
-		for _, node := range nodes {
-			if node.fileNode.packageName == s.fileNode.packageName && node.receiver == "" {
-				return node
-			}
+func (s *FunctionNode) addCallee(node *FunctionNode, kind string, embeddedPath []string, pos token.Pos) {
+	s.callee[node.getIdentity()] = node
+	node.caller[s.getIdentity()] = s
+	// 直接调用优先于回调，调用点的位置全部保留
+	edge, ok := s.calleeEdges[node.getIdentity()]
+	if !ok || edge.kind == callKindCallback {
+		positions := make([]token.Position, 0)
+		if ok {
+			positions = edge.positions
 		}
-	} else if strings.Contains(line, "."+functionName+"(") {
-		pos := strings.Index(line, "."+functionName+"(") - 1
-		tmp := bytes.NewBuffer([]byte{})
-		for pos >= 0 && line[pos] != ' ' {
-			tmp.WriteByte(line[pos])
-			pos -= 1
+		edge = &callEdge{
+			caller:       s,
+			callee:       node,
+			kind:         kind,
+			embeddedPath: embeddedPath,
+			positions:    positions,
 		}
+		s.calleeEdges[node.getIdentity()] = edge
+	}
+	edge.positions = append(edge.positions, s.fileNode.nodeManager.fset.Position(pos))
+}
 
-		// 是否在模块中
-		name := tmp.String()
-		if _, ok := s.fileNode.importers[name]; ok {
-			for _, node := range nodes {
-				if node.fileNode.packageName == name {
-					return node
-				}
+// 解析函数体中的全部调用，同时建立调用者关系，函数字面量中的调用归属于字面量节点
+func (s *FunctionNode) mergeCallee() {
+	body := s.getBody()
+	if body == nil {
+		return
+	}
+	nodeManager := s.fileNode.nodeManager
+
+	// go/defer启动的调用
+	kinds := make(map[*ast.CallExpr]string, 0)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.GoStmt:
+			kinds[x.Call] = callKindGo
+		case *ast.DeferStmt:
+			kinds[x.Call] = callKindDefer
+		}
+		return true
+	})
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			if literal, ok := s.children[x]; ok {
+				s.addCallee(literal, literal.literalKind, nil, x.Pos())
+			}
+			return false
+		case *ast.CallExpr:
+			if node, embeddedPath := nodeManager.getCalledFunction(x); node != nil {
+				s.addCallee(node, kinds[x], embeddedPath, getCallPos(x))
+			} else if node := s.fileNode.getImportedFunction(x.Fun); node != nil {
+				s.addCallee(node, kinds[x], nil, getCallPos(x))
 			}
-		} else {
-			for _, node := range nodes {
-				if node.receiver == s.receiver {
-					return node
+			// 作为参数传递的函数，比如注册的回调
+			for _, arg := range x.Args {
+				if node := nodeManager.getReferencedFunction(arg); node != nil {
+					s.addCallee(node, callKindCallback, nil, arg.Pos())
 				}
 			}
 		}
-		if len(nodes) == 1 {
-			return nodes[0]
+		return true
+	})
+}
+
+// 调用点的位置取被调用的函数名，链式调用时能区分每一次调用
+func getCallPos(call *ast.CallExpr) token.Pos {
+	fun := ast.Unparen(call.Fun)
+	switch x := fun.(type) {
+	case *ast.IndexExpr:
+		fun = x.X
+	case *ast.IndexListExpr:
+		fun = x.X
+	}
+	if x, ok := fun.(*ast.SelectorExpr); ok {
+		return x.Sel.Pos()
+	}
+	return fun.Pos()
+}
+
+// 找到函数对应的类型对象
+func (s *FunctionNode) getObject() *types.Func {
+	nodeManager := s.fileNode.nodeManager
+	if s.literal != nil {
+		return nil
+	}
+	if s.decl != nil {
+		object, _ := nodeManager.info.Defs[s.decl.Name].(*types.Func)
+		return object
+	}
+
+	// 接口中声明的方法
+	pkg := nodeManager.typesPackages[s.fileNode.packagePath]
+	if pkg == nil {
+		return nil
+	}
+	typeName, ok := pkg.Scope().Lookup(s.receiver).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	iface, ok := typeName.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		if method := iface.ExplicitMethod(i); method.Name() == s.name {
+			return method
 		}
-		//// 优先找同一个模块里面的
-		//fmt.Println(s.fileNode.file)
-		//fmt.Println(s.getIdentity())
-		//fmt.Println(line)
-		//for i, node := range nodes {
-		//	fmt.Printf("node %d: %s\n", i , node.getIdentity())
-		//}
-		//
-		//index := -1
-		//fmt.Println("输入对应的node序号:")
-		//_, _ = fmt.Scanf("%d", &index)
-		//if index >= 0 {
-		//	s.callee[nodes[index].getIdentity()] = nodes[index]
-		//}
-		//if len(nodes) == 1 && unicode.IsLower([]rune(nodes[0].name)[0]) && index == 0{
-		//	s.fileNode.nodeManager.knownModuleFunction[nodes[0].name] = true
-		//}
 	}
 	return nil
 }
 
-func (s *FunctionNode) deduceCallee() {
-	lines := strings.Split(s.content, "\n")
-	// 跳过自己
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// 跳过注释
-		if strings.HasPrefix(line, "func") || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "*") {
-			continue
-		}
+// 函数字面量用椭圆表示，go启动的用蓝色，递归调用中的函数用红色，测试代码填充底色
+func (s *FunctionNode) getNodeAttributes() string {
+	attributes := make([]string, 0)
+	if s.literal == nil {
+		attributes = append(attributes, "shape=\"box\"")
+	} else {
+		attributes = append(attributes, "shape=\"ellipse\"")
+	}
 
-		// 跳过自己
-		if strings.Contains(line, s.name+"(") {
-			continue
-		}
-		nodeManager := s.fileNode.nodeManager
-		for functionName, nodes := range nodeManager.allFunctions {
-			// 遍历全部现有的函数，查看是否存在调用关系
-			node := s.checkCalleeInvolved(line, functionName, nodes)
-			if node != nil {
-				s.callee[node.getIdentity()] = node
-			}
-		}
+	styles := make([]string, 0)
+	if s.literal != nil {
+		styles = append(styles, "dashed")
+	}
+	if s.fileNode.isTest {
+		styles = append(styles, "filled")
+		attributes = append(attributes, "fillcolor=\"lightyellow\"")
+	}
+	if len(styles) > 0 {
+		attributes = append(attributes, fmt.Sprintf("style=\"%s\"", strings.Join(styles, ",")))
+	}
+
+	// 递归调用中的函数标红
+	if s.fileNode.nodeManager.callCycleOf[s.getIdentity()] > 0 {
+		attributes = append(attributes, "color=\"red\"")
+	} else if s.literal != nil && s.literalKind == callKindGo {
+		attributes = append(attributes, "color=\"blue\"")
+	}
+	if source := s.fileNode.nodeManager.config.getSourceAttributes(s.position); source != "" {
+		attributes = append(attributes, source)
+	}
+	return strings.Join(attributes, ", ")
+}
+
+// 接口中声明的方法
+func (s *FunctionNode) isInterfaceMethod() bool {
+	return s.decl == nil && s.literal == nil
+}
+
+// go test识别的测试入口，Test、Benchmark以及Fuzz函数的唯一参数分别是*testing.T、*testing.B以及*testing.F，
+// Example函数没有参数，全部都没有返回值
+func (s *FunctionNode) isTestEntry() bool {
+	if !s.fileNode.isTest || s.decl == nil || s.receiver != "" || s.decl.Type.TypeParams != nil {
+		return false
+	}
+	switch {
+	case isTestName(s.name, "Test"):
+		return s.hasTestingParam("T")
+	case isTestName(s.name, "Benchmark"):
+		return s.hasTestingParam("B")
+	case isTestName(s.name, "Fuzz"):
+		return s.hasTestingParam("F")
+	case isTestName(s.name, "Example"):
+		return len(s.decl.Type.Params.List) == 0 && s.decl.Type.Results == nil
+	}
+	return false
+}
+
+// 测试文件中的TestMain(m *testing.M)
+func (s *FunctionNode) isTestMain() bool {
+	return s.fileNode.isTest && s.decl != nil && s.receiver == "" && s.name == "TestMain" && s.hasTestingParam("M")
+}
+
+// 和go test的规则一致，前缀之后是名称的结尾或者不是小写字母，比如Testify不是测试
+func isTestName(name string, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// 只有一个*testing.<name>类型的参数并且没有返回值
+func (s *FunctionNode) hasTestingParam(name string) bool {
+	if s.object == nil {
+		// 类型检查失败时按源码判断
+		params := s.decl.Type.Params.List
+		return s.decl.Type.Results == nil && len(params) == 1 && len(params[0].Names) <= 1 && types.ExprString(params[0].Type) == "*testing."+name
+	}
+	signature, ok := s.object.Type().(*types.Signature)
+	if !ok || signature.Params().Len() != 1 || signature.Results().Len() != 0 {
+		return false
+	}
+	pointer, ok := signature.Params().At(0).Type().(*types.Pointer)
+	if !ok {
+		return false
 	}
+	named, ok := pointer.Elem().(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "testing" && named.Obj().Name() == name
 }
 
 func (s *FunctionNode) DrawCallerNode(content *bytes.Buffer, record map[string]bool, count int) {
-	s.deduceCaller()
 	content.WriteString("\n")
-	content.WriteString(fmt.Sprintf("%s [label=%s, shape=\"box\"];", s.getIdentity(), s.getIdentity()))
+	content.WriteString(fmt.Sprintf("%s [label=%s, %s];", s.getIdentity(), s.getLabel(), s.getNodeAttributes()))
 
 	record[s.getIdentity()] = true
 	count--
@@ -248,7 +509,7 @@ func (s *FunctionNode) DrawCallerRelation(content *bytes.Buffer, record map[stri
 
 	for _, caller := range s.caller {
 		if tempRecord[caller.getIdentity()] == false {
-			content.WriteString(fmt.Sprintf("%s->%s;", caller.getIdentity(), s.getIdentity()))
+			content.WriteString(fmt.Sprintf("%s->%s%s;", caller.getIdentity(), s.getIdentity(), caller.calleeEdges[s.getIdentity()].getAttributes()))
 			content.WriteString("\n")
 			tempRecord[caller.getIdentity()] = true
 		}
@@ -267,9 +528,8 @@ func (s *FunctionNode) DrawCallerRelation(content *bytes.Buffer, record map[stri
 }
 
 func (s *FunctionNode) DrawCalleeNode(content *bytes.Buffer, record map[string]bool, count int) {
-	s.deduceCallee()
 	content.WriteString("\n")
-	content.WriteString(fmt.Sprintf("%s [label=%s, shape=\"box\"];", s.getIdentity(), s.getIdentity()))
+	content.WriteString(fmt.Sprintf("%s [label=%s, %s];", s.getIdentity(), s.getLabel(), s.getNodeAttributes()))
 
 	record[s.getIdentity()] = true
 	count--
@@ -286,7 +546,7 @@ func (s *FunctionNode) DrawCalleeRelation(content *bytes.Buffer, record map[stri
 
 	for _, callee := range s.callee {
 		if tempRecord[callee.getIdentity()] == false {
-			content.WriteString(fmt.Sprintf("%s->%s;", s.getIdentity(), callee.getIdentity()))
+			content.WriteString(fmt.Sprintf("%s->%s%s;", s.getIdentity(), callee.getIdentity(), s.calleeEdges[callee.getIdentity()].getAttributes()))
 			content.WriteString("\n")
 			tempRecord[callee.getIdentity()] = true
 		}
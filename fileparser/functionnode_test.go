@@ -0,0 +1,95 @@
+package fileparser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRepeatableFunctions(t *testing.T) {
+	nodeManager := parseSource(t, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"a.go": `package main
+
+var registry = map[string]func(){}
+
+func init() {
+	registry["a"] = func() { first() }
+}
+
+func init() {
+	registry["b"] = func() { second() }
+}
+
+func _() { unreachable() }
+
+func first() {}
+
+func second() {}
+
+func unreachable() {}
+`,
+		"b.go": `package main
+
+type handler struct{}
+
+func (handler) _() {}
+
+func (handler) _() {}
+
+func init() {
+	go func() { third() }()
+}
+
+func third() {}
+
+func main() {}
+`,
+	})
+
+	graph := nodeManager.Graph()
+	callees := make(map[string][]string, 0)
+	for _, node := range graph.Nodes() {
+		if node.Kind != NodeFunction {
+			continue
+		}
+		ids := make([]string, 0)
+		for _, edge := range graph.OutEdges(node.ID) {
+			if edge.Kind.IsCall() {
+				ids = append(ids, edge.To)
+			}
+		}
+		sort.Strings(ids)
+		callees[node.ID] = ids
+	}
+
+	want := map[string][]string{
+		"example.com/app:init@a.go:5:1":      {"example.com/app:init@a.go:5:1$1"},
+		"example.com/app:init@a.go:5:1$1":    {"example.com/app:first"},
+		"example.com/app:init@a.go:9:1":      {"example.com/app:init@a.go:9:1$1"},
+		"example.com/app:init@a.go:9:1$1":    {"example.com/app:second"},
+		"example.com/app:init@b.go:9:1":      {"example.com/app:init@b.go:9:1$1"},
+		"example.com/app:init@b.go:9:1$1":    {"example.com/app:third"},
+		"example.com/app:_@a.go:13:1":        {"example.com/app:unreachable"},
+		"example.com/app:handler._@b.go:5:1": {},
+		"example.com/app:handler._@b.go:7:1": {},
+		"example.com/app:first":              {},
+		"example.com/app:second":             {},
+		"example.com/app:third":              {},
+		"example.com/app:unreachable":        {},
+		"example.com/app:main":               {},
+	}
+	if !reflect.DeepEqual(callees, want) {
+		t.Errorf("callees = %v, want %v", callees, want)
+	}
+
+	if got, want := nodeManager.GetFunctionCalleeCodeSnippet("main//init@a.go:9:1"), 3; len(got) != want {
+		t.Errorf("GetFunctionCalleeCodeSnippet(init@a.go:9:1) = %v, want %d functions", got, want)
+	}
+	wantDead := map[string][]string{
+		"main": {`"main//_@a.go:13:1"`, `"main/handler/_@b.go:5:1"`, `"main/handler/_@b.go:7:1"`, `"main//unreachable"`},
+	}
+	if got := nodeManager.GetDeadFunctions(); !reflect.DeepEqual(got, wantDead) {
+		t.Errorf("GetDeadFunctions() = %v, want %v", got, wantDead)
+	}
+}
@@ -0,0 +1,379 @@
+package fileparser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// 全局变量的访问方式
+const (
+	accessRead  = "read"
+	accessWrite = "write"
+)
+
+// 包级别的变量以及常量
+type GlobalNode struct {
+	fileNode *FileNode
+	name     string
+	// var或者const
+	kind     string
+	content  string
+	ident    *ast.Ident
+	object   types.Object
+	position token.Position
+	doc      string
+	// 读写全局变量的函数，key为函数的identity
+	readers map[string]*FunctionNode
+	writers map[string]*FunctionNode
+	// 类型引用的结构体、接口以及具名类型
+	typeRelations []*fieldRelation
+}
+
+func NewGlobalNode(fileNode *FileNode, name string, kind string, content string) *GlobalNode {
+	return &GlobalNode{
+		fileNode:      fileNode,
+		name:          name,
+		kind:          kind,
+		content:       content,
+		readers:       make(map[string]*FunctionNode, 0),
+		writers:       make(map[string]*FunctionNode, 0),
+		typeRelations: make([]*fieldRelation, 0),
+	}
+}
+
+func (g *GlobalNode) getIdentity() string {
+	return g.fileNode.getIdentity(g.name)
+}
+
+func (g *GlobalNode) getDisplayName() string {
+	return "\"" + g.fileNode.getPackageDisplayName() + "/" + g.name + "\""
+}
+
+func (g *GlobalNode) getSnippet() string {
+	return formatSnippet(g.position, g.doc, g.content)
+}
+
+// 类型名相对于当前包展示
+func (g *GlobalNode) getType() string {
+	if g.object == nil {
+		return ""
+	}
+	return types.TypeString(g.object.Type(), func(pkg *types.Package) string {
+		if pkg.Path() == g.fileNode.packagePath {
+			return ""
+		}
+		return pkg.Name()
+	})
+}
+
+// 除了init以外还有函数写入的变量，是共享的可变状态
+func (g *GlobalNode) isMutable() bool {
+	if g.kind != "var" {
+		return false
+	}
+	for _, writer := range g.writers {
+		if !writer.inInit() {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *GlobalNode) Merge() {
+	nodeManager := g.fileNode.nodeManager
+	g.object = nodeManager.info.Defs[g.ident]
+	if g.object == nil {
+		return
+	}
+	nodeManager.allGlobals[g.getIdentity()] = g
+	nodeManager.globalObjects[g.object] = g
+
+	walkType(g.object.Type(), nil, func(object *types.TypeName, containers []string) {
+		if relation := nodeManager.newFieldRelation(object, g.name, containers); relation != nil {
+			g.typeRelations = append(g.typeRelations, relation)
+		}
+	})
+}
+
+func (g *GlobalNode) getLabel() string {
+	label := fmt.Sprintf("%s: %s\\l\\n----\\lpackage: %s\\l\\nfile: %s\\l\\ntype: %s\\l%s", g.kind, g.name, g.fileNode.getPackageDisplayName(), g.fileNode.fileNodeTagName, g.getType(), g.fileNode.nodeManager.config.getDocLabel(g.doc))
+	return strings.ReplaceAll(label, "\"", "\\\"")
+}
+
+// 常量用灰色，被init以外的函数写入的变量标红
+func (g *GlobalNode) getNodeAttributes() string {
+	attributes := []string{"shape=\"cylinder\""}
+	if g.kind == "const" {
+		attributes = append(attributes, "color=\"gray\"")
+	} else if g.isMutable() {
+		attributes = append(attributes, "color=\"red\"", "style=\"bold\"")
+	}
+	if source := g.fileNode.nodeManager.config.getSourceAttributes(g.position); source != "" {
+		attributes = append(attributes, source)
+	}
+	return strings.Join(attributes, ", ")
+}
+
+func (g *GlobalNode) DrawNode(content *bytes.Buffer, record map[string]bool) {
+	content.WriteString(fmt.Sprintf("%s [label=\"%s\", %s];\n", g.getIdentity(), g.getLabel(), g.getNodeAttributes()))
+	record[g.getIdentity()] = true
+
+	for _, functionNode := range g.getAccessors() {
+		if !record[functionNode.getIdentity()] {
+			content.WriteString(fmt.Sprintf("%s [label=%s, %s];\n", functionNode.getIdentity(), functionNode.getLabel(), functionNode.getNodeAttributes()))
+			record[functionNode.getIdentity()] = true
+		}
+	}
+	for _, relation := range g.typeRelations {
+		if relation.structNode != nil && !record[relation.getIdentity()] {
+			relation.structNode.DrawNode(content, record, 1)
+		}
+		if relation.interfaceNode != nil && !record[relation.getIdentity()] {
+			relation.interfaceNode.DrawNode(content, record)
+		}
+		if relation.typeNode != nil && !record[relation.getIdentity()] {
+			relation.typeNode.DrawNode(content, record, 1)
+		}
+	}
+}
+
+// 读写关系和图模型一样从函数指向全局变量，表示函数依赖的变量，写入用红色粗线，读取用虚线，类型引用标注经过的容器
+func (g *GlobalNode) DrawRelation(content *bytes.Buffer) {
+	for _, functionNode := range g.getAccessors() {
+		if _, ok := g.writers[functionNode.getIdentity()]; ok {
+			content.WriteString(fmt.Sprintf("%s -> %s [label=\"%s\", color=\"red\", style=\"bold\"];\n", functionNode.getIdentity(), g.getIdentity(), accessWrite))
+		}
+		if _, ok := g.readers[functionNode.getIdentity()]; ok {
+			content.WriteString(fmt.Sprintf("%s -> %s [label=\"%s\", style=\"dashed\"];\n", functionNode.getIdentity(), g.getIdentity(), accessRead))
+		}
+	}
+	tempRecord := make(map[string]bool, 0)
+	for _, relation := range g.typeRelations {
+		key := relation.getIdentity() + relation.getLabel()
+		if !tempRecord[key] {
+			content.WriteString(fmt.Sprintf("%s -> %s%s;\n", g.getIdentity(), relation.getIdentity(), relation.getAttributes()))
+			tempRecord[key] = true
+		}
+	}
+}
+
+// 读写全局变量的函数，按identity排序
+func (g *GlobalNode) getAccessors() []*FunctionNode {
+	accessors := make(map[string]*FunctionNode, 0)
+	for identity, functionNode := range g.readers {
+		accessors[identity] = functionNode
+	}
+	for identity, functionNode := range g.writers {
+		accessors[identity] = functionNode
+	}
+	identities := make([]string, 0, len(accessors))
+	for identity := range accessors {
+		identities = append(identities, identity)
+	}
+	sort.Strings(identities)
+
+	result := make([]*FunctionNode, 0, len(identities))
+	for _, identity := range identities {
+		result = append(result, accessors[identity])
+	}
+	return result
+}
+
+// 解析包级别的var以及const声明，函数中的局部声明不算
+func parseGlobals(fileNode *FileNode, f *ast.File, content []byte, base token.Pos) []*GlobalNode {
+	globals := make([]*GlobalNode, 0)
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || (genDecl.Tok != token.VAR && genDecl.Tok != token.CONST) {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			doc := valueSpec.Doc
+			if doc == nil && !genDecl.Lparen.IsValid() {
+				doc = genDecl.Doc
+			}
+			for _, ident := range valueSpec.Names {
+				if ident.Name == "_" {
+					continue
+				}
+				globalNode := NewGlobalNode(fileNode, ident.Name, genDecl.Tok.String(), genDecl.Tok.String()+" "+getNodeSource(content, base, valueSpec, 0))
+				globalNode.ident = ident
+				globalNode.position = fileNode.nodeManager.fset.Position(ident.Pos())
+				globalNode.doc = doc.Text()
+				globals = append(globals, globalNode)
+			}
+		}
+	}
+	return globals
+}
+
+// 表达式最终读写的全局变量，比如 g.field，g[key]，*g，pkg.G，返回变量对应的标识符
+func (n *NodeManager) getAccessedGlobal(expr ast.Expr) *ast.Ident {
+	for {
+		switch x := expr.(type) {
+		case *ast.ParenExpr:
+			expr = x.X
+		case *ast.StarExpr:
+			expr = x.X
+		case *ast.IndexExpr:
+			expr = x.X
+		case *ast.SelectorExpr:
+			if ident, ok := x.X.(*ast.Ident); ok {
+				if _, ok := n.info.Uses[ident].(*types.PkgName); ok {
+					expr = x.Sel
+					continue
+				}
+			}
+			expr = x.X
+		case *ast.Ident:
+			if _, ok := n.globalObjects[n.info.Uses[x]]; ok {
+				return x
+			}
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// 函数体中对全局变量的读写，函数字面量中的读写归属于字面量节点，
+// 按每一处引用判断读写，比如 Counter = Counter + 1 同时有读和写
+func (s *FunctionNode) mergeGlobals() {
+	body := s.getBody()
+	if body == nil {
+		return
+	}
+	nodeManager := s.fileNode.nodeManager
+
+	// 赋值，自增自减，取地址以及值类型变量调用指针接收者的方法都认为是写入，
+	// 自增自减以及 += 这类复合赋值同时也是读取
+	writes := make(map[*ast.Ident]bool, 0)
+	reads := make(map[*ast.Ident]bool, 0)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			for _, lhs := range x.Lhs {
+				if ident := nodeManager.getAccessedGlobal(lhs); ident != nil {
+					writes[ident] = true
+					if x.Tok != token.ASSIGN && x.Tok != token.DEFINE {
+						reads[ident] = true
+					}
+				}
+			}
+		case *ast.IncDecStmt:
+			if ident := nodeManager.getAccessedGlobal(x.X); ident != nil {
+				writes[ident] = true
+				reads[ident] = true
+			}
+		case *ast.UnaryExpr:
+			if x.Op == token.AND {
+				if ident := nodeManager.getAccessedGlobal(x.X); ident != nil {
+					writes[ident] = true
+				}
+			}
+		case *ast.SelectorExpr:
+			if selection, ok := nodeManager.info.Selections[x]; ok && selection.Kind() == types.MethodVal {
+				_, pointerReceiver := selection.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer)
+				_, pointerValue := selection.Recv().Underlying().(*types.Pointer)
+				// 值类型的变量调用指针接收者的方法时会取地址
+				if pointerReceiver && !pointerValue {
+					if ident := nodeManager.getAccessedGlobal(x.X); ident != nil {
+						writes[ident] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.Ident:
+			globalNode, ok := nodeManager.globalObjects[nodeManager.info.Uses[x]]
+			if !ok {
+				return true
+			}
+			if writes[x] {
+				globalNode.writers[s.getIdentity()] = s
+			}
+			if !writes[x] || reads[x] {
+				globalNode.readers[s.getIdentity()] = s
+			}
+		}
+		return true
+	})
+}
+
+func (n *NodeManager) mergeGlobals() {
+	for _, package_ := range n.packages {
+		for _, filenode := range package_ {
+			for _, globalNode := range filenode.globalNodes {
+				globalNode.Merge()
+			}
+		}
+	}
+	for _, nodes := range n.allFunctions {
+		for _, node := range nodes {
+			node.mergeGlobals()
+		}
+	}
+}
+
+// 绘制包中的全局变量，读写它们的函数以及类型引用的结构体，baseName为空时绘制整个项目
+func (n *NodeManager) DrawGlobals(baseName string, constants bool) {
+	packagePath := ""
+	if baseName != "" {
+		fileNodes := make([]*FileNode, 0)
+		for _, package_ := range n.packages {
+			fileNodes = append(fileNodes, package_[0])
+		}
+		fileNode := n.matchPackage(fileNodes, baseName)
+		if fileNode == nil {
+			return
+		}
+		packagePath = fileNode.packagePath
+	}
+
+	globals := make([]*GlobalNode, 0)
+	for _, globalNode := range n.allGlobals {
+		if packagePath != "" && globalNode.fileNode.packagePath != packagePath {
+			continue
+		}
+		if globalNode.kind == "const" && !constants {
+			continue
+		}
+		globals = append(globals, globalNode)
+	}
+	sort.Slice(globals, func(i, j int) bool {
+		return globals[i].getIdentity() < globals[j].getIdentity()
+	})
+
+	content := n.newGraph()
+	record := make(map[string]bool, 0)
+	for _, globalNode := range globals {
+		globalNode.DrawNode(content, record)
+	}
+	for _, globalNode := range globals {
+		globalNode.DrawRelation(content)
+	}
+	content.WriteString("}")
+
+	name := "globals"
+	if fileNode := n.packages[packagePath]; len(fileNode) > 0 {
+		name += "_" + strings.ReplaceAll(fileNode[0].getPackageDisplayName(), "/", "_")
+	}
+	n.writeGraph(name, content)
+}
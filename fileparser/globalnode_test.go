@@ -0,0 +1,53 @@
+package fileparser
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGlobalAccessDirection(t *testing.T) {
+	nodeManager := parseSource(t, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"main.go": `package main
+
+var counter int
+
+func inc() { counter++ }
+
+func get() int { return counter }
+
+func main() { inc(); get() }
+`,
+	})
+
+	globalNode := nodeManager.allGlobals[`"example.com/app:counter"`]
+	if globalNode == nil {
+		t.Fatal("global counter not found")
+	}
+	content := bytes.NewBuffer([]byte{})
+	globalNode.DrawRelation(content)
+	edges := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(content.String()), "\n") {
+		edges = append(edges, line[:strings.Index(line, " [")])
+	}
+	want := []string{
+		`"example.com/app:get" -> "example.com/app:counter"`,
+		`"example.com/app:inc" -> "example.com/app:counter"`,
+		`"example.com/app:inc" -> "example.com/app:counter"`,
+	}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("DrawRelation() edges = %v, want %v", edges, want)
+	}
+
+	accesses := make([]string, 0)
+	for _, edge := range nodeManager.Graph().InEdges("example.com/app:counter") {
+		accesses = append(accesses, edge.From+" "+string(edge.Kind))
+	}
+	sort.Strings(accesses)
+	if want := []string{"example.com/app:get read", "example.com/app:inc read", "example.com/app:inc write"}; !reflect.DeepEqual(accesses, want) {
+		t.Errorf("Graph() edges to counter = %v, want %v", accesses, want)
+	}
+}
@@ -0,0 +1,439 @@
+package fileparser
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 节点的种类
+type NodeKind string
+
+const (
+	NodeStruct    NodeKind = "struct"
+	NodeInterface NodeKind = "interface"
+	NodeType      NodeKind = "type"
+	NodeAlias     NodeKind = "alias"
+	NodeFunction  NodeKind = "function"
+	NodeVar       NodeKind = "var"
+	NodeConst     NodeKind = "const"
+)
+
+// 边的种类
+type EdgeKind string
+
+const (
+	// 调用关系
+	EdgeCall     EdgeKind = "call"
+	EdgeGo       EdgeKind = "go"
+	EdgeDefer    EdgeKind = "defer"
+	EdgeCallback EdgeKind = "callback"
+	EdgeClosure  EdgeKind = "closure"
+	// 接口方法到实现方法
+	EdgeDispatch EdgeKind = "dispatch"
+
+	// 类型之间的关系
+	EdgeEmbeds     EdgeKind = "embeds"
+	EdgeField      EdgeKind = "field"
+	EdgeImplements EdgeKind = "implements"
+	EdgeTypeSet    EdgeKind = "type set"
+	EdgeUnderlying EdgeKind = "underlying"
+	EdgeAlias      EdgeKind = "alias"
+	EdgeMethod     EdgeKind = "method"
+
+	// 全局变量的读写，都从函数指向全局变量，以及全局变量的类型
+	EdgeRead  EdgeKind = "read"
+	EdgeWrite EdgeKind = "write"
+	EdgeType  EdgeKind = "type"
+)
+
+// 是否是调用关系，沿调用关系查询时同时经过接口分派
+func (k EdgeKind) IsCall() bool {
+	switch k {
+	case EdgeCall, EdgeGo, EdgeDefer, EdgeCallback, EdgeClosure, EdgeDispatch:
+		return true
+	}
+	return false
+}
+
+// 图中的节点，ID为不带引号的identity，导入路径和包内的名称用冒号分隔，
+// 比如 example.com/app/service:Repo.Find，example.com/app/service:NewRepo，example.com/app/model:User
+// Attributes中是和种类相关的附加信息，比如函数的receiver，变量的type，构成环的节点有cycle
+type Node struct {
+	Kind       NodeKind
+	ID         string
+	Name       string
+	Package    string
+	File       string
+	Position   token.Position
+	Doc        string
+	Attributes map[string]string
+}
+
+// 图中的边，字段关系的Label为字段名，调用关系的Label为经过的嵌入字段
+type Edge struct {
+	Kind       EdgeKind
+	From       string
+	To         string
+	Label      string
+	Containers []string
+	Positions  []token.Position
+}
+
+// 只读的图模型，全部查询都返回副本
+type Graph struct {
+	nodes map[string]*Node
+	edges []*Edge
+	// 按照加入的顺序，字段保持声明的顺序
+	out map[string][]*Edge
+	in  map[string][]*Edge
+}
+
+func newGraph() *Graph {
+	return &Graph{
+		nodes: make(map[string]*Node, 0),
+		edges: make([]*Edge, 0),
+		out:   make(map[string][]*Edge, 0),
+		in:    make(map[string][]*Edge, 0),
+	}
+}
+
+// ID重复说明identity的规则有遗漏，记录下来并保留先加入的节点
+func (g *Graph) addNode(node *Node) {
+	if existing, ok := g.nodes[node.ID]; ok {
+		Log.Sugar().Warnf("duplicated graph node:%s, kind:%s and %s, keep the first one", node.ID, existing.Kind, node.Kind)
+		return
+	}
+	g.nodes[node.ID] = node
+}
+
+func (g *Graph) addEdge(kind EdgeKind, from string, to string, label string) *Edge {
+	edge := &Edge{Kind: kind, From: trimQuote(from), To: trimQuote(to), Label: label}
+	g.edges = append(g.edges, edge)
+	g.out[edge.From] = append(g.out[edge.From], edge)
+	g.in[edge.To] = append(g.in[edge.To], edge)
+	return edge
+}
+
+func (n Node) clone() Node {
+	attributes := make(map[string]string, len(n.Attributes))
+	for k, v := range n.Attributes {
+		attributes[k] = v
+	}
+	n.Attributes = attributes
+	return n
+}
+
+func (e Edge) clone() Edge {
+	e.Containers = append([]string(nil), e.Containers...)
+	e.Positions = append([]token.Position(nil), e.Positions...)
+	return e
+}
+
+func cloneEdges(edges []*Edge) []Edge {
+	result := make([]Edge, 0, len(edges))
+	for _, edge := range edges {
+		result = append(result, edge.clone())
+	}
+	return result
+}
+
+// 按ID查找节点，ID可以带引号
+func (g *Graph) Node(id string) (Node, bool) {
+	node, ok := g.nodes[trimQuote(id)]
+	if !ok {
+		return Node{}, false
+	}
+	return node.clone(), true
+}
+
+// 全部节点，按ID排序
+func (g *Graph) Nodes() []Node {
+	ids := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := make([]Node, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, g.nodes[id].clone())
+	}
+	return result
+}
+
+// 全部边，按起点、终点以及标签排序
+func (g *Graph) Edges() []Edge {
+	result := cloneEdges(g.edges)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].From != result[j].From {
+			return result[i].From < result[j].From
+		}
+		if result[i].To != result[j].To {
+			return result[i].To < result[j].To
+		}
+		return result[i].Label < result[j].Label
+	})
+	return result
+}
+
+// 从节点出发的边
+func (g *Graph) OutEdges(id string) []Edge {
+	return cloneEdges(g.out[trimQuote(id)])
+}
+
+// 指向节点的边
+func (g *Graph) InEdges(id string) []Edge {
+	return cloneEdges(g.in[trimQuote(id)])
+}
+
+// 结构体的字段以及嵌入关系，按字段的声明顺序
+func (g *Graph) Fields(id string) []Edge {
+	result := make([]Edge, 0)
+	for _, edge := range g.out[trimQuote(id)] {
+		if edge.Kind == EdgeField || edge.Kind == EdgeEmbeds {
+			result = append(result, edge.clone())
+		}
+	}
+	return result
+}
+
+// depth层以内直接或间接调用了函数的全部函数，depth小于等于0时不限制层数
+func (g *Graph) Callers(id string, depth int) []Node {
+	return g.walkCalls(trimQuote(id), depth, func(id string) []string {
+		result := make([]string, 0)
+		for _, edge := range g.in[id] {
+			if edge.Kind.IsCall() {
+				result = append(result, edge.From)
+			}
+		}
+		return result
+	})
+}
+
+// depth层以内函数直接或间接调用的全部函数，depth小于等于0时不限制层数
+func (g *Graph) Callees(id string, depth int) []Node {
+	return g.walkCalls(trimQuote(id), depth, func(id string) []string {
+		result := make([]string, 0)
+		for _, edge := range g.out[id] {
+			if edge.Kind.IsCall() {
+				result = append(result, edge.To)
+			}
+		}
+		return result
+	})
+}
+
+// 按层做广度优先遍历，结果按层排列，同一层内按ID排序，不包含起点
+func (g *Graph) walkCalls(id string, depth int, next func(string) []string) []Node {
+	result := make([]Node, 0)
+	if _, ok := g.nodes[id]; !ok {
+		return result
+	}
+	visited := map[string]bool{id: true}
+	level := []string{id}
+	for i := 0; len(level) > 0 && (depth <= 0 || i < depth); i++ {
+		nextLevel := make([]string, 0)
+		for _, current := range level {
+			for _, neighbor := range next(current) {
+				if !visited[neighbor] {
+					visited[neighbor] = true
+					nextLevel = append(nextLevel, neighbor)
+				}
+			}
+		}
+		sort.Strings(nextLevel)
+		for _, neighbor := range nextLevel {
+			if node, ok := g.nodes[neighbor]; ok {
+				result = append(result, node.clone())
+			}
+		}
+		level = nextLevel
+	}
+	return result
+}
+
+// 解析结果的图模型，Merge之后第一次调用时构建
+func (n *NodeManager) Graph() *Graph {
+	if n.graph == nil {
+		n.graph = n.buildGraph()
+	}
+	return n.graph
+}
+
+func newGraphNode(kind NodeKind, identity string, displayName string, fileNode *FileNode, position token.Position, doc string) *Node {
+	return &Node{
+		Kind:       kind,
+		ID:         trimQuote(identity),
+		Name:       trimQuote(displayName),
+		Package:    fileNode.packagePath,
+		File:       fileNode.file,
+		Position:   position,
+		Doc:        doc,
+		Attributes: make(map[string]string, 0),
+	}
+}
+
+func (n *NodeManager) buildGraph() *Graph {
+	graph := newGraph()
+
+	for _, structNode := range n.allStructs {
+		node := newGraphNode(NodeStruct, structNode.getIdentity(), structNode.getDisplayName(), structNode.fileNode, structNode.position, structNode.doc)
+		node.Attributes["exported"] = strconv.FormatBool(ast.IsExported(structNode.name))
+		if typeParams := formatTypeParams(structNode.typeParams); typeParams != "" {
+			node.Attributes["typeParams"] = typeParams
+		}
+		if cycle := n.typeCycleOf[structNode.getIdentity()]; cycle > 0 {
+			node.Attributes["cycle"] = strconv.Itoa(cycle)
+		}
+		graph.addNode(node)
+		for _, relation := range structNode.fieldRelations {
+			kind := EdgeField
+			if structNode.isEmbeddedRelation(relation) {
+				kind = EdgeEmbeds
+			}
+			edge := graph.addEdge(kind, structNode.getIdentity(), relation.getIdentity(), relation.field)
+			edge.Containers = relation.containers
+		}
+	}
+
+	for _, interfaceNode := range n.allInterfaces {
+		node := newGraphNode(NodeInterface, interfaceNode.getIdentity(), interfaceNode.getDisplayName(), interfaceNode.fileNode, interfaceNode.position, interfaceNode.doc)
+		node.Attributes["exported"] = strconv.FormatBool(ast.IsExported(interfaceNode.name))
+		if typeParams := formatTypeParams(interfaceNode.typeParams); typeParams != "" {
+			node.Attributes["typeParams"] = typeParams
+		}
+		graph.addNode(node)
+		for _, embedded := range interfaceNode.embeddedInterfaces {
+			graph.addEdge(EdgeEmbeds, interfaceNode.getIdentity(), embedded.getIdentity(), "")
+		}
+		for _, structNode := range interfaceNode.typeSetStructs {
+			graph.addEdge(EdgeTypeSet, interfaceNode.getIdentity(), structNode.getIdentity(), "")
+		}
+		for identity, structNode := range interfaceNode.implementStruct {
+			graph.addEdge(EdgeImplements, structNode.getIdentity(), interfaceNode.getIdentity(), interfaceNode.getImplementLabel(identity))
+		}
+		for identity, typeNode := range interfaceNode.implementTypes {
+			graph.addEdge(EdgeImplements, typeNode.getIdentity(), interfaceNode.getIdentity(), interfaceNode.getImplementLabel(identity))
+		}
+	}
+
+	for _, typeNode := range n.allTypes {
+		kind, edgeKind := NodeType, EdgeUnderlying
+		if typeNode.alias {
+			kind, edgeKind = NodeAlias, EdgeAlias
+		}
+		node := newGraphNode(kind, typeNode.getIdentity(), typeNode.getDisplayName(), typeNode.fileNode, typeNode.position, typeNode.doc)
+		node.Attributes["exported"] = strconv.FormatBool(ast.IsExported(typeNode.name))
+		if typeNode.typeSpec != nil {
+			node.Attributes["underlying"] = types.ExprString(typeNode.typeSpec.Type)
+		}
+		if typeParams := formatTypeParams(typeNode.typeParams); typeParams != "" {
+			node.Attributes["typeParams"] = typeParams
+		}
+		if cycle := n.typeCycleOf[typeNode.getIdentity()]; cycle > 0 {
+			node.Attributes["cycle"] = strconv.Itoa(cycle)
+		}
+		graph.addNode(node)
+		for _, relation := range typeNode.typeRelations {
+			edge := graph.addEdge(edgeKind, typeNode.getIdentity(), relation.getIdentity(), "")
+			edge.Containers = relation.containers
+		}
+		for _, method := range typeNode.getMethods() {
+			graph.addEdge(EdgeMethod, typeNode.getIdentity(), method.getIdentity(), "")
+		}
+	}
+
+	for _, globalNode := range n.allGlobals {
+		node := newGraphNode(NodeKind(globalNode.kind), globalNode.getIdentity(), globalNode.getDisplayName(), globalNode.fileNode, globalNode.position, globalNode.doc)
+		node.Attributes["exported"] = strconv.FormatBool(ast.IsExported(globalNode.name))
+		node.Attributes["type"] = globalNode.getType()
+		node.Attributes["mutable"] = strconv.FormatBool(globalNode.isMutable())
+		graph.addNode(node)
+		for _, functionNode := range globalNode.getAccessors() {
+			if _, ok := globalNode.writers[functionNode.getIdentity()]; ok {
+				graph.addEdge(EdgeWrite, functionNode.getIdentity(), globalNode.getIdentity(), "")
+			}
+			if _, ok := globalNode.readers[functionNode.getIdentity()]; ok {
+				graph.addEdge(EdgeRead, functionNode.getIdentity(), globalNode.getIdentity(), "")
+			}
+		}
+		for _, relation := range globalNode.typeRelations {
+			edge := graph.addEdge(EdgeType, globalNode.getIdentity(), relation.getIdentity(), "")
+			edge.Containers = relation.containers
+		}
+	}
+
+	for _, functionNodes := range n.allFunctions {
+		for _, functionNode := range functionNodes {
+			graph.addNode(functionNode.getGraphNode())
+			for _, callEdge := range functionNode.getCalleeEdges() {
+				edge := graph.addEdge(callEdge.getKind(), functionNode.getIdentity(), callEdge.callee.getIdentity(), "")
+				if len(callEdge.embeddedPath) > 0 {
+					edge.Label = "via " + strings.Join(callEdge.embeddedPath, ".")
+				}
+				edge.Positions = callEdge.positions
+			}
+			for _, method := range n.getImplementMethods(functionNode) {
+				graph.addEdge(EdgeDispatch, functionNode.getIdentity(), method.getIdentity(), "")
+			}
+		}
+	}
+	return graph
+}
+
+func (s *FunctionNode) getGraphNode() *Node {
+	node := newGraphNode(NodeFunction, s.getIdentity(), s.getDisplayName(), s.fileNode, s.position, s.doc)
+	node.Attributes["exported"] = strconv.FormatBool(s.literal == nil && ast.IsExported(s.name))
+	node.Attributes["test"] = strconv.FormatBool(s.fileNode.isTest)
+	if s.receiver != "" {
+		node.Attributes["receiver"] = s.receiver
+	}
+	if s.decl != nil {
+		node.Attributes["signature"] = strings.TrimPrefix(types.ExprString(s.decl.Type), "func")
+	}
+	if s.isInterfaceMethod() {
+		node.Attributes["interface"] = "true"
+	}
+	if cycle := s.fileNode.nodeManager.callCycleOf[s.getIdentity()]; cycle > 0 {
+		node.Attributes["cycle"] = strconv.Itoa(cycle)
+	}
+	if s.literal != nil {
+		node.Attributes["literal"] = s.getLiteralKind()
+		node.Attributes["parent"] = trimQuote(s.parent.getIdentity())
+	}
+	return node
+}
+
+// 函数字面量的使用方式，立即调用的记为call
+func (s *FunctionNode) getLiteralKind() string {
+	if s.literalKind == callKindCall {
+		return string(EdgeCall)
+	}
+	return s.literalKind
+}
+
+// 调用关系按被调用函数的identity排序
+func (s *FunctionNode) getCalleeEdges() []*callEdge {
+	identities := make([]string, 0, len(s.calleeEdges))
+	for identity := range s.calleeEdges {
+		identities = append(identities, identity)
+	}
+	sort.Strings(identities)
+
+	result := make([]*callEdge, 0, len(identities))
+	for _, identity := range identities {
+		result = append(result, s.calleeEdges[identity])
+	}
+	return result
+}
+
+func (e *callEdge) getKind() EdgeKind {
+	if e.kind == callKindCall {
+		return EdgeCall
+	}
+	return EdgeKind(e.kind)
+}
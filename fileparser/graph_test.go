@@ -0,0 +1,44 @@
+package fileparser
+
+import "testing"
+
+func TestGraphAddDuplicatedNode(t *testing.T) {
+	graph := newGraph()
+	graph.addNode(&Node{Kind: NodeFunction, ID: "a", Name: "first"})
+	graph.addNode(&Node{Kind: NodeStruct, ID: "a", Name: "second"})
+
+	node, ok := graph.Node("a")
+	if !ok || node.Kind != NodeFunction || node.Name != "first" {
+		t.Errorf("Node(a) = %+v %v, want the first node", node, ok)
+	}
+	if nodes := graph.Nodes(); len(nodes) != 1 {
+		t.Errorf("Nodes() = %v, want 1 node", nodes)
+	}
+}
+
+func TestMergeOnce(t *testing.T) {
+	nodeManager := parseSource(t, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"main.go": `package main
+
+type Config struct{ Name string }
+
+func main() { run() }
+
+func run() {}
+`,
+	})
+	nodes := nodeManager.Graph().Nodes()
+
+	// 重复的Merge被忽略，不会重复归并出节点和关系
+	nodeManager.Merge()
+	if got := nodeManager.Graph().Nodes(); len(got) != len(nodes) {
+		t.Errorf("Nodes() after second Merge = %v, want %v", got, nodes)
+	}
+	if got := nodeManager.allFunctions["run"]; len(got) != 1 {
+		t.Errorf("allFunctions[run] after second Merge = %v, want 1 function", got)
+	}
+	if err := nodeManager.Inspect(nodes[0].File); err == nil {
+		t.Error("Inspect after Merge = nil, want error")
+	}
+}
@@ -0,0 +1,188 @@
+package fileparser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 项目根目录下的忽略文件，语义和.gitignore一致
+const ignoreFileName = ".visualizationignore"
+
+// 默认忽略的目录以及文件，可以在忽略文件中用!重新包含
+var defaultIgnorePatterns = []string{"vendor/", "*.pb.go"}
+
+type ignoreRule struct {
+	pattern string
+	// 规则的来源，比如 .visualizationignore:3
+	source  string
+	negate  bool
+	dirOnly bool
+	regexp  *regexp.Regexp
+}
+
+func (r *ignoreRule) String() string {
+	return r.source + ": " + r.pattern
+}
+
+// 解析一条gitignore规则，空行以及注释返回nil
+func newIgnoreRule(pattern string, source string, prefixMatch bool) *ignoreRule {
+	line := strings.TrimRight(pattern, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	rule := &ignoreRule{pattern: line, source: source}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+
+	// 中间或者开头带/的规则相对于根目录，否则匹配任意层级
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := bytes.NewBufferString("^")
+	if !anchored {
+		expr.WriteString("(.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end == -1 {
+				expr.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(line):
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(line[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// 包含规则匹配到目录时，目录下的文件也算匹配
+	if prefixMatch {
+		expr.WriteString("(/.*)?")
+	}
+	expr.WriteString("$")
+
+	compiled, err := regexp.Compile(expr.String())
+	if err != nil {
+		Log.Sugar().Warnf("invalid pattern:%s from %s, error:%s", pattern, source, err.Error())
+		return nil
+	}
+	rule.regexp = compiled
+	return rule
+}
+
+func (r *ignoreRule) match(path string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	return r.regexp.MatchString(path)
+}
+
+// 源文件过滤，依次使用默认规则，忽略文件以及配置中的排除规则，后面的规则优先
+type FileFilter struct {
+	root    string
+	rules   []*ignoreRule
+	include []*ignoreRule
+}
+
+func NewFileFilter(root string, config *Config) *FileFilter {
+	filter := &FileFilter{
+		root:    root,
+		rules:   make([]*ignoreRule, 0),
+		include: make([]*ignoreRule, 0),
+	}
+
+	for _, pattern := range defaultIgnorePatterns {
+		if rule := newIgnoreRule(pattern, "default", false); rule != nil {
+			filter.rules = append(filter.rules, rule)
+		}
+	}
+
+	ignoreFile := filepath.Join(root, ignoreFileName)
+	if file, err := os.Open(ignoreFile); err == nil {
+		scanner := bufio.NewScanner(file)
+		for line := 1; scanner.Scan(); line++ {
+			if rule := newIgnoreRule(scanner.Text(), fmt.Sprintf("%s:%d", ignoreFileName, line), false); rule != nil {
+				filter.rules = append(filter.rules, rule)
+			}
+		}
+		file.Close()
+	}
+
+	for _, pattern := range config.Exclude {
+		if rule := newIgnoreRule(pattern, "exclude", false); rule != nil {
+			filter.rules = append(filter.rules, rule)
+		}
+	}
+	for _, pattern := range config.Include {
+		if rule := newIgnoreRule(pattern, "include", true); rule != nil {
+			filter.include = append(filter.include, rule)
+		}
+	}
+	return filter
+}
+
+// 判断路径是否需要跳过，同时返回跳过的原因
+func (f *FileFilter) Skip(path string, isDir bool) (bool, string) {
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil {
+		return false, ""
+	}
+	rel = filepath.ToSlash(rel)
+
+	var matched *ignoreRule
+	for _, rule := range f.rules {
+		if rule.match(rel, isDir) {
+			matched = rule
+		}
+	}
+	if matched != nil && !matched.negate {
+		return true, matched.String()
+	}
+
+	// 配置了包含规则时，文件至少要匹配其中一条
+	if !isDir && len(f.include) > 0 {
+		for _, rule := range f.include {
+			if rule.match(rel, isDir) {
+				return false, ""
+			}
+		}
+		return true, "not matched by any include pattern"
+	}
+	return false, ""
+}
@@ -0,0 +1,139 @@
+package fileparser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRuleMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"扩展名匹配根目录", "*.log", "a.log", false, true},
+		{"扩展名匹配任意层级", "*.log", "dir/sub/a.log", false, true},
+		{"扩展名不匹配前缀", "*.log", "a.log.txt", false, false},
+		{"开头的/只匹配根目录", "/build", "build", true, true},
+		{"开头的/不匹配子目录", "/build", "src/build", true, false},
+		{"中间的/相对于根目录", "docs/*.md", "docs/a.md", false, true},
+		{"中间的/不匹配子目录", "docs/*.md", "x/docs/a.md", false, false},
+		{"*不跨越目录", "docs/*.md", "docs/sub/a.md", false, false},
+		{"开头的**匹配根目录", "**/testdata", "testdata", true, true},
+		{"开头的**匹配任意层级", "**/testdata", "a/b/testdata", true, true},
+		{"结尾的**匹配目录下的全部文件", "gen/**", "gen/a/b.go", false, true},
+		{"结尾的**不匹配目录本身", "gen/**", "gen", true, false},
+		{"中间的**匹配零层目录", "a/**/b", "a/b", false, true},
+		{"中间的**匹配多层目录", "a/**/b", "a/x/y/b", false, true},
+		{"中间的**不匹配没有分隔的名称", "a/**/b", "ab", false, false},
+		{"结尾的/只匹配目录", "tmp/", "tmp", true, true},
+		{"结尾的/不匹配文件", "tmp/", "tmp", false, false},
+		{"结尾的/匹配任意层级的目录", "tmp/", "x/tmp", true, true},
+		{"?匹配单个字符", "file?.go", "file1.go", false, true},
+		{"?不匹配多个字符", "file?.go", "file10.go", false, false},
+		{"字符集合", "[abc].go", "a.go", false, true},
+		{"字符集合不匹配", "[abc].go", "d.go", false, false},
+		{"取反的字符集合", "[!abc].go", "d.go", false, true},
+		{"取反的字符集合不匹配", "[!abc].go", "a.go", false, false},
+		{"转义的!是普通字符", "\\!important", "!important", false, true},
+		{"正则中的特殊字符按原样匹配", "a+b.go", "a+b.go", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := newIgnoreRule(tt.pattern, "test", false)
+			if rule == nil {
+				t.Fatalf("newIgnoreRule(%q) = nil", tt.pattern)
+			}
+			if got := rule.match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("%q match %q (dir=%v) = %v, want %v, regexp %s", tt.pattern, tt.path, tt.isDir, got, tt.want, rule.regexp)
+			}
+		})
+	}
+}
+
+func TestNewIgnoreRule(t *testing.T) {
+	tests := []struct {
+		pattern string
+		isNil   bool
+		negate  bool
+		dirOnly bool
+	}{
+		{pattern: "", isNil: true},
+		{pattern: "   ", isNil: true},
+		{pattern: "# comment", isNil: true},
+		{pattern: "!", isNil: true},
+		{pattern: "/", isNil: true},
+		{pattern: "!keep.go", negate: true},
+		{pattern: "\\!keep.go"},
+		{pattern: "\\#file"},
+		{pattern: "out/", dirOnly: true},
+		{pattern: "!out/", negate: true, dirOnly: true},
+		{pattern: "trailing.go  "},
+	}
+	for _, tt := range tests {
+		rule := newIgnoreRule(tt.pattern, "test", false)
+		if (rule == nil) != tt.isNil {
+			t.Errorf("newIgnoreRule(%q) = %v, want nil %v", tt.pattern, rule, tt.isNil)
+			continue
+		}
+		if rule == nil {
+			continue
+		}
+		if rule.negate != tt.negate || rule.dirOnly != tt.dirOnly {
+			t.Errorf("newIgnoreRule(%q) negate=%v dirOnly=%v, want %v %v", tt.pattern, rule.negate, rule.dirOnly, tt.negate, tt.dirOnly)
+		}
+	}
+
+	// 包含规则匹配到目录时，目录下的文件也算匹配
+	rule := newIgnoreRule("service", "include", true)
+	for path, want := range map[string]bool{"service/a.go": true, "x/service/a/b.go": true, "services/a.go": false} {
+		if got := rule.match(path, false); got != want {
+			t.Errorf("include service match %q = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestFileFilterSkip(t *testing.T) {
+	root := t.TempDir()
+	ignore := strings.Join([]string{
+		"# 生成的代码",
+		"*.gen.go",
+		"!keep.gen.go",
+		"/out/",
+		"!vendor/",
+	}, "\n")
+	if err := os.WriteFile(filepath.Join(root, ignoreFileName), []byte(ignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+	filter := NewFileFilter(root, NewConfig(WithExclude("internal/legacy"), WithInclude("service", "model/*.go")))
+
+	tests := []struct {
+		path   string
+		isDir  bool
+		skip   bool
+		reason string
+	}{
+		{path: "service/a.gen.go", skip: true, reason: ".visualizationignore:2: *.gen.go"},
+		{path: "service/keep.gen.go", skip: false},
+		{path: "out", isDir: true, skip: true, reason: ".visualizationignore:4: /out/"},
+		{path: "service/out", isDir: true, skip: false},
+		{path: "vendor", isDir: true, skip: false},
+		{path: "service/a.pb.go", skip: true, reason: "default: *.pb.go"},
+		{path: "internal/legacy", isDir: true, skip: true, reason: "exclude: internal/legacy"},
+		{path: "internal", isDir: true, skip: false},
+		{path: "service/a.go", skip: false},
+		{path: "model/user.go", skip: false},
+		{path: "model/sub/user.go", skip: true, reason: "not matched by any include pattern"},
+		{path: "main.go", skip: true, reason: "not matched by any include pattern"},
+	}
+	for _, tt := range tests {
+		skip, reason := filter.Skip(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir)
+		if skip != tt.skip || reason != tt.reason {
+			t.Errorf("Skip(%q) = %v %q, want %v %q", tt.path, skip, reason, tt.skip, tt.reason)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package fileparser
+
+import (
+	"go/ast"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 主版本号的后缀，比如 /v2 以及 gopkg.in 的 .v3
+var (
+	majorVersionElem   = regexp.MustCompile(`^v[0-9]+$`)
+	majorVersionSuffix = regexp.MustCompile(`\.v[0-9]+$`)
+)
+
+// 根据导入路径推测包名，比如 gopkg.in/yaml.v3 为 yaml，github.com/x/y/v2 为 y，github.com/x/go-sh 为 sh
+func guessPackageName(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if majorVersionElem.MatchString(name) && len(elems) > 1 {
+		name = elems[len(elems)-2]
+	}
+	name = majorVersionSuffix.ReplaceAllString(name, "")
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	return strings.NewReplacer("-", "_", ".", "_").Replace(name)
+}
+
+// 记录导入的包，匿名导入忽略，点导入的包单独记录
+func (f *FileNode) addImport(name string, importPath string) {
+	switch name {
+	case "_":
+	case ".":
+		f.dotImports = append(f.dotImports, importPath)
+	default:
+		f.importers[name] = importPath
+	}
+}
+
+// 导入的包在文件中使用的名称，没有别名时使用包的真实名称
+func (f *FileNode) getImportName(spec *ast.ImportSpec, importPath string) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	// 导入失败的包由类型检查伪造，不是完整的包
+	if pkgName, ok := f.nodeManager.info.Implicits[spec].(*types.PkgName); ok && pkgName.Imported().Complete() {
+		return pkgName.Imported().Name()
+	}
+	if fileNodes := f.nodeManager.packages[importPath]; len(fileNodes) > 0 {
+		return fileNodes[0].packageName
+	}
+	return guessPackageName(importPath)
+}
+
+// 类型检查之后用真实的包名重新建立导入关系
+func (f *FileNode) mergeImports() {
+	f.importers = make(map[string]string, 0)
+	f.dotImports = make([]string, 0)
+	for _, spec := range f.astFile.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		f.addImport(f.getImportName(spec, importPath), importPath)
+	}
+}
+
+// 类型信息缺失时，比如存在导入环，根据导入的包名找到被调用的函数
+func (f *FileNode) getImportedFunction(fun ast.Expr) *FunctionNode {
+	fun = ast.Unparen(fun)
+	switch x := fun.(type) {
+	case *ast.IndexExpr:
+		fun = x.X
+	case *ast.IndexListExpr:
+		fun = x.X
+	}
+
+	switch x := fun.(type) {
+	case *ast.SelectorExpr:
+		ident, ok := x.X.(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		// 局部变量等覆盖了包名时不是包中的函数
+		if object := f.nodeManager.info.Uses[ident]; object != nil {
+			if _, ok := object.(*types.PkgName); !ok {
+				return nil
+			}
+		}
+		if importPath, ok := f.importers[ident.Name]; ok {
+			return f.nodeManager.getPackageFunction(importPath, x.Sel.Name)
+		}
+	case *ast.Ident:
+		if f.nodeManager.info.Uses[x] != nil {
+			return nil
+		}
+		// 点导入的包中的函数不带包名
+		for _, importPath := range f.dotImports {
+			if node := f.nodeManager.getPackageFunction(importPath, x.Name); node != nil {
+				return node
+			}
+		}
+	}
+	return nil
+}
+
+// 包中的普通函数
+func (n *NodeManager) getPackageFunction(packagePath string, name string) *FunctionNode {
+	for _, fileNode := range n.packages[packagePath] {
+		for _, functionNode := range fileNode.functionNodes[name] {
+			if functionNode.receiver == "" && functionNode.decl != nil {
+				return functionNode
+			}
+		}
+	}
+	return nil
+}
@@ -3,57 +3,328 @@ package fileparser
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
 )
 
 type InterfaceNode struct {
 	fileNode        *FileNode
 	name            string
 	content         string
-	implementStruct map[string]bool
-	methods         map[string]string
+	implementStruct map[string]*StructNode
+	// 实现了接口的其他具名类型，比如 type HandlerFunc func(...)
+	implementTypes map[string]*TypeNode
+	// 只有指针类型才实现了接口
+	implementByPointer map[string]bool
+	embeddedInterfaces map[string]*InterfaceNode
+	// 类型约束中出现的结构体
+	typeSetStructs map[string]*StructNode
+	// 嵌入的接口以及类型约束
+	embeds      []ast.Expr
+	methods     map[string]string
+	methodNames []string
+	typeParams  []*typeParam
+	typeSpec    *ast.TypeSpec
+	object      *types.TypeName
+	position    token.Position
+	doc         string
 }
 
 func NewInterfaceNode(fileNode *FileNode, name string, content string, methods map[string]string) *InterfaceNode {
 	return &InterfaceNode{
-		fileNode:        fileNode,
-		name:            name,
-		content:         content,
-		implementStruct: make(map[string]bool, 0),
-		methods:         methods,
+		fileNode:           fileNode,
+		name:               name,
+		content:            content,
+		implementStruct:    make(map[string]*StructNode, 0),
+		implementTypes:     make(map[string]*TypeNode, 0),
+		implementByPointer: make(map[string]bool, 0),
+		embeddedInterfaces: make(map[string]*InterfaceNode, 0),
+		typeSetStructs:     make(map[string]*StructNode, 0),
+		methods:            methods,
 	}
 }
 
+func (i *InterfaceNode) getSnippet() string {
+	return formatSnippet(i.position, i.doc, i.content)
+}
+
 func (i *InterfaceNode) getIdentity() string {
-	return "\"" + i.fileNode.packageName + "/" + i.name + "\""
+	return i.fileNode.getIdentity(i.name)
+}
+
+func (i *InterfaceNode) getDisplayName() string {
+	return "\"" + i.fileNode.getPackageDisplayName() + "/" + i.name + "\""
+}
+
+// 根据方法集判断哪些结构体以及具名类型实现了接口，方法签名、指针接收者以及嵌入字段提升的方法都由类型系统处理
+func (i *InterfaceNode) mergeImplement(structs map[string]*StructNode, typeNodes map[string]*TypeNode) {
+	ifaces := i.getMethodSets()
+	if len(ifaces) == 0 {
+		return
+	}
+
+	for identity, structNode := range structs {
+		implemented, byPointer := implementsAnyInterface(structNode.object, ifaces)
+		if !implemented {
+			continue
+		}
+		i.implementStruct[identity] = structNode
+		i.implementByPointer[identity] = byPointer
+		structNode.implementInterfaces[i.getIdentity()] = i
+	}
+
+	for identity, typeNode := range typeNodes {
+		// 别名和它代表的类型是同一个类型，只记在原类型上
+		if typeNode.alias {
+			continue
+		}
+		implemented, byPointer := implementsAnyInterface(typeNode.object, ifaces)
+		if !implemented {
+			continue
+		}
+		i.implementTypes[identity] = typeNode
+		i.implementByPointer[identity] = byPointer
+		typeNode.implementInterfaces[i.getIdentity()] = i
+	}
+}
+
+// 可以用来比较实现关系的接口，空接口以及类型约束返回nil，
+// 泛型接口返回项目中用到的实例化，比如 Store[int]，没有用到时返回泛型接口本身，
+// 方法中的类型参数在比较时可以替换成任意满足约束的类型
+func (i *InterfaceNode) getMethodSets() []*types.Interface {
+	if i.object == nil {
+		return nil
+	}
+	named, ok := i.object.Type().(*types.Named)
+	if !ok {
+		return nil
+	}
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok || iface.NumMethods() == 0 || !iface.IsMethodSet() {
+		return nil
+	}
+	if named.TypeParams().Len() == 0 {
+		return []*types.Interface{iface}
+	}
+
+	result := make([]*types.Interface, 0)
+	for _, instance := range i.fileNode.nodeManager.info.Instances {
+		instanceNamed, ok := instance.Type.(*types.Named)
+		if !ok || instanceNamed.Origin() != named || instanceNamed == named {
+			continue
+		}
+		instanceIface, ok := instanceNamed.Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+		duplicated := false
+		for _, existing := range result {
+			if types.Identical(existing, instanceIface) {
+				duplicated = true
+				break
+			}
+		}
+		if !duplicated {
+			result = append(result, instanceIface)
+		}
+	}
+	if len(result) == 0 {
+		return []*types.Interface{iface}
+	}
+	return result
+}
+
+// 实现了其中任意一个接口即可，值类型实现时不需要标注指针
+func implementsAnyInterface(object *types.TypeName, ifaces []*types.Interface) (bool, bool) {
+	implemented, byPointer := false, true
+	for _, iface := range ifaces {
+		if ok, pointer := implementsInterface(object, iface); ok {
+			implemented = true
+			byPointer = byPointer && pointer
+		}
+	}
+	return implemented, implemented && byPointer
+}
+
+// 类型是否实现了接口，以及是否只有指针类型才实现了接口，
+// 泛型类型按方法名和签名匹配，类型参数可以替换成任意满足约束的类型，比如 Mem[T] 的 Get() T 实现 Get() int
+func implementsInterface(object *types.TypeName, iface *types.Interface) (bool, bool) {
+	if object == nil {
+		return false, false
+	}
+	t, ok := object.Type().(*types.Named)
+	if !ok || types.IsInterface(t) {
+		return false, false
+	}
+	if t.TypeParams().Len() == 0 && !containsTypeParam(iface) {
+		if types.Implements(t, iface) {
+			return true, false
+		}
+		if types.Implements(types.NewPointer(t), iface) {
+			return true, true
+		}
+		return false, false
+	}
+	if matchMethods(t, types.NewMethodSet(t), iface) {
+		return true, false
+	}
+	if matchMethods(t, types.NewMethodSet(types.NewPointer(t)), iface) {
+		return true, true
+	}
+	return false, false
+}
+
+// 方法集中是否有接口的全部方法，签名中的类型参数在所有方法中要替换成相同的类型
+func matchMethods(t *types.Named, methodSet *types.MethodSet, iface *types.Interface) bool {
+	unifier := make(typeUnifier, 0)
+	for i := 0; i < iface.NumMethods(); i++ {
+		method := iface.Method(i)
+		selection := methodSet.Lookup(method.Pkg(), method.Name())
+		if selection == nil {
+			return false
+		}
+		signature, ok := selection.Obj().Type().(*types.Signature)
+		if !ok {
+			return false
+		}
+		// 每个方法的接收者都声明了自己的类型参数，对应到类型声明中的类型参数上
+		if recvTypeParams := signature.RecvTypeParams(); recvTypeParams.Len() == t.TypeParams().Len() {
+			for j := 0; j < recvTypeParams.Len(); j++ {
+				unifier.unify(recvTypeParams.At(j), t.TypeParams().At(j))
+			}
+		}
+		if !unifier.unify(signature, method.Type()) {
+			return false
+		}
+	}
+	return unifier.satisfies()
 }
 
-func (i *InterfaceNode) mergeImplement(functionNames map[string]map[string]bool) {
-label:
-	for receiver, functions := range functionNames {
-		// 查看接口中的全部方法是否都实现了
-		for method, _ := range i.methods {
-			if _, ok := functions[method]; !ok {
-				continue label
+// 找出接口中嵌入的项目内接口，以及类型约束中引用的结构体
+func (i *InterfaceNode) mergeEmbedded() {
+	nodeManager := i.fileNode.nodeManager
+	for _, embed := range i.embeds {
+		for _, term := range getTypeTerms(embed) {
+			object := nodeManager.getReferencedType(term)
+			if object == nil {
+				continue
+			}
+			if node, ok := nodeManager.interfaceObjects[object]; ok {
+				i.embeddedInterfaces[node.getIdentity()] = node
+			}
+			if node, ok := nodeManager.structObjects[object]; ok {
+				i.typeSetStructs[node.getIdentity()] = node
 			}
 		}
-		i.implementStruct[receiver] = true
 	}
 }
 
 func (i *InterfaceNode) getLabelDescribe() string {
 	buffer := bytes.NewBuffer([]byte{})
-	for _, describe := range i.methods {
-		buffer.WriteString(fmt.Sprintf("%s\\l\\n", describe))
+	for _, embed := range i.embeds {
+		buffer.WriteString(fmt.Sprintf("%s\\l\\n", types.ExprString(embed)))
 	}
-	label := fmt.Sprintf("interface: %s\\l\\n----\\lpackage: %s\\l\\nfile: %s\\l-----\\l%s", i.name, i.fileNode.packageName, i.fileNode.fileNodeTagName, buffer.String())
+	for _, name := range i.methodNames {
+		buffer.WriteString(fmt.Sprintf("%s\\l\\n", i.methods[name]))
+	}
+	label := fmt.Sprintf("interface: %s%s\\l\\n----\\lpackage: %s\\l\\nfile: %s\\l%s-----\\l%s", i.name, formatTypeParams(i.typeParams), i.fileNode.getPackageDisplayName(), i.fileNode.fileNodeTagName, i.fileNode.nodeManager.config.getDocLabel(i.doc), buffer.String())
 	return label
 }
 
+// 绘制接口以及它嵌入的接口
 func (i *InterfaceNode) DrawNode(content *bytes.Buffer, record map[string]bool) {
 	if record[i.getIdentity()] == true {
 		return
 	}
-	content.WriteString(fmt.Sprintf("%s [label=\"%s\", shape=\"box\"];", i.getIdentity(), i.getLabelDescribe()))
+	content.WriteString(fmt.Sprintf("%s [label=\"%s\", shape=\"box\", %s];", i.getIdentity(), i.getLabelDescribe(), i.fileNode.nodeManager.config.getSourceAttributes(i.position)))
 	content.WriteString("\n")
 	record[i.getIdentity()] = true
+
+	for _, node := range i.embeddedInterfaces {
+		node.DrawNode(content, record)
+	}
+}
+
+// 接口的继承关系，以及类型约束中的结构体
+func (i *InterfaceNode) DrawEmbeddedRelation(content *bytes.Buffer, record map[string]bool) {
+	if record[i.getIdentity()] == true {
+		return
+	}
+	record[i.getIdentity()] = true
+
+	for _, node := range i.embeddedInterfaces {
+		content.WriteString(fmt.Sprintf("%s -> %s [arrowhead=\"empty\"]", i.getIdentity(), node.getIdentity()))
+		content.WriteString("\n")
+	}
+	for _, node := range i.typeSetStructs {
+		content.WriteString(fmt.Sprintf("%s -> %s [style=\"dotted\", label=\"type set\"]", i.getIdentity(), node.getIdentity()))
+		content.WriteString("\n")
+	}
+
+	for _, node := range i.embeddedInterfaces {
+		node.DrawEmbeddedRelation(content, record)
+	}
+}
+
+// 绘制接口，嵌入的接口以及实现了接口的结构体
+func (i *InterfaceNode) DrawImplementNode(content *bytes.Buffer, record map[string]bool, count int) {
+	i.DrawNode(content, record)
+	for _, node := range i.typeSetStructs {
+		if record[node.getIdentity()] == false {
+			node.DrawNode(content, record, 1)
+		}
+	}
+
+	for _, node := range i.implementStruct {
+		if record[node.getIdentity()] == false {
+			node.DrawNode(content, record, count)
+		}
+	}
+	for _, node := range i.implementTypes {
+		node.DrawNode(content, record, count)
+	}
+}
+
+func (i *InterfaceNode) DrawImplementRelation(content *bytes.Buffer, record map[string]bool, count int) {
+	i.DrawEmbeddedRelation(content, record)
+
+	for identity := range i.implementStruct {
+		i.drawImplementEdge(content, identity)
+	}
+	for identity := range i.implementTypes {
+		i.drawImplementEdge(content, identity)
+	}
+
+	// 实现者包含的类型
+	if count > 1 {
+		for _, node := range i.implementStruct {
+			if record[node.getIdentity()] == false {
+				node.DrawRelation(content, record, count-1)
+			}
+		}
+		for _, node := range i.implementTypes {
+			if record[node.getIdentity()] == false {
+				node.DrawRelation(content, record, count-1)
+			}
+		}
+	}
+}
+
+// 只有指针类型实现了接口时标注*
+func (i *InterfaceNode) getImplementLabel(identity string) string {
+	if i.implementByPointer[identity] {
+		return "*"
+	}
+	return ""
+}
+
+// 实现者到接口的边，identity为实现者的identity
+func (i *InterfaceNode) drawImplementEdge(content *bytes.Buffer, identity string) {
+	if i.implementByPointer[identity] {
+		content.WriteString(fmt.Sprintf("%s -> %s [style=\"dashed\", arrowhead=\"empty\", label=\"*\"]", identity, i.getIdentity()))
+	} else {
+		content.WriteString(fmt.Sprintf("%s -> %s [style=\"dashed\", arrowhead=\"empty\"]", identity, i.getIdentity()))
+	}
+	content.WriteString("\n")
 }
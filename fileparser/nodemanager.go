@@ -7,53 +7,215 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 )
 
 type NodeManager struct {
 	projectPath         string
+	config              *Config
 	packages            map[string][]*FileNode
-	structTypes         map[string]map[string]*StructNode
-	functionNames       map[string]map[string]bool
-	interfaceNames      map[string]map[string]*InterfaceNode
 	allFunctions        map[string][]*FunctionNode
 	allStructs          map[string]*StructNode
 	allInterfaces       map[string]*InterfaceNode
+	allTypes            map[string]*TypeNode
+	allGlobals          map[string]*GlobalNode
 	knownModuleFunction map[string]bool
+	packageNames        map[string]map[string]bool
+
+	// 类型检查相关
+	fset             *token.FileSet
+	moduleDir        string
+	modulePath       string
+	info             *types.Info
+	importer         types.ImporterFrom
+	typesPackages    map[string]*types.Package
+	functionObjects  map[*types.Func]*FunctionNode
+	structObjects    map[*types.TypeName]*StructNode
+	interfaceObjects map[*types.TypeName]*InterfaceNode
+	typeObjects      map[*types.TypeName]*TypeNode
+	globalObjects    map[types.Object]*GlobalNode
+
+	// 项目外的包是否是标准库，key为导入路径
+	stdlibPackages map[string]bool
+
+	// 接口方法和实现方法的对应关系
+	implementMethods map[*FunctionNode][]*FunctionNode
+	interfaceMethods map[*FunctionNode][]*FunctionNode
+
+	// 没有解析的文件以及目录，value为跳过的原因
+	skippedFiles map[string]string
+	// 解析过程中遇到的全部错误
+	parseErrors ParseErrors
+
+	// Merge只能执行一次，之后不能再解析新的文件
+	merged bool
+
+	// 对外提供的图模型
+	graph *Graph
+
+	// 调用图以及结构体包含关系中构成环的节点，key为identity，value为强连通分量的序号，从1开始
+	callCycleOf map[string]int
+	typeCycleOf map[string]int
 }
 
-
-
 func (n *NodeManager) GetStructCodeSnippet(baseName string) map[string]string {
-	structNode, ok := n.allStructs[baseName]
-	if !ok {
+	structNode := n.getMatchedStruct(baseName)
+	if structNode == nil {
 		return map[string]string{}
 	}
 	return structNode.GetCodeSnippet()
 }
 
+// 把名称拆成包和剩下的部分，包既可以是完整的导入路径，也可以是短包名
+func splitBaseName(baseName string, count int) (string, []string) {
+	elems := strings.Split(strings.Trim(baseName, "\""), "/")
+	if len(elems) <= count {
+		return "", nil
+	}
+	return strings.Join(elems[:len(elems)-count], "/"), elems[len(elems)-count:]
+}
+
+// 优先按导入路径匹配，短包名只有在不冲突时才能匹配上
+func (n *NodeManager) matchPackage(fileNodes []*FileNode, packageName string) *FileNode {
+	candidates := make([]*FileNode, 0)
+	for _, fileNode := range fileNodes {
+		if fileNode.packagePath == packageName {
+			return fileNode
+		}
+		if fileNode.packageName == packageName {
+			candidates = append(candidates, fileNode)
+		}
+	}
+	if len(candidates) > 1 {
+		Log.Sugar().Warnf("package name:%s is ambiguous, use the import path instead", packageName)
+		return nil
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return nil
+}
+
+func (n *NodeManager) getMatchedStruct(baseName string) *StructNode {
+	if structNode, ok := n.allStructs["\""+strings.Trim(baseName, "\"")+"\""]; ok {
+		return structNode
+	}
+	packageName, elems := splitBaseName(baseName, 1)
+	if elems == nil {
+		return nil
+	}
+
+	structNodes := make(map[*FileNode]*StructNode, 0)
+	fileNodes := make([]*FileNode, 0)
+	for _, structNode := range n.allStructs {
+		if structNode.name == elems[0] {
+			structNodes[structNode.fileNode] = structNode
+			fileNodes = append(fileNodes, structNode.fileNode)
+		}
+	}
+	return structNodes[n.matchPackage(fileNodes, packageName)]
+}
+
+func (n *NodeManager) getMatchedInterface(baseName string) *InterfaceNode {
+	if interfaceNode, ok := n.allInterfaces["\""+strings.Trim(baseName, "\"")+"\""]; ok {
+		return interfaceNode
+	}
+	packageName, elems := splitBaseName(baseName, 1)
+	if elems == nil {
+		return nil
+	}
+
+	interfaceNodes := make(map[*FileNode]*InterfaceNode, 0)
+	fileNodes := make([]*FileNode, 0)
+	for _, interfaceNode := range n.allInterfaces {
+		if interfaceNode.name == elems[0] {
+			interfaceNodes[interfaceNode.fileNode] = interfaceNode
+			fileNodes = append(fileNodes, interfaceNode.fileNode)
+		}
+	}
+	return interfaceNodes[n.matchPackage(fileNodes, packageName)]
+}
+
+func (n *NodeManager) getMatchedType(baseName string) *TypeNode {
+	if typeNode, ok := n.allTypes["\""+strings.Trim(baseName, "\"")+"\""]; ok {
+		return typeNode
+	}
+	packageName, elems := splitBaseName(baseName, 1)
+	if elems == nil {
+		return nil
+	}
+
+	typeNodes := make(map[*FileNode]*TypeNode, 0)
+	fileNodes := make([]*FileNode, 0)
+	for _, typeNode := range n.allTypes {
+		if typeNode.name == elems[0] {
+			typeNodes[typeNode.fileNode] = typeNode
+			fileNodes = append(fileNodes, typeNode.fileNode)
+		}
+	}
+	return typeNodes[n.matchPackage(fileNodes, packageName)]
+}
+
+// 实现了接口的结构体以及具名类型，value表示是否只有指针类型才实现了接口
+func (n *NodeManager) GetImplementations(baseName string) map[string]bool {
+	result := make(map[string]bool, 0)
+	interfaceNode := n.getMatchedInterface(baseName)
+	if interfaceNode == nil {
+		return result
+	}
+	for identity, structNode := range interfaceNode.implementStruct {
+		result[structNode.getDisplayName()] = interfaceNode.implementByPointer[identity]
+	}
+	for identity, typeNode := range interfaceNode.implementTypes {
+		result[typeNode.getDisplayName()] = interfaceNode.implementByPointer[identity]
+	}
+	return result
+}
+
+// 结构体或者具名类型实现了的接口，value表示是否只有指针类型才实现了接口
+func (n *NodeManager) GetImplementedInterfaces(baseName string) map[string]bool {
+	result := make(map[string]bool, 0)
+	if structNode := n.getMatchedStruct(baseName); structNode != nil {
+		for _, interfaceNode := range structNode.implementInterfaces {
+			result[interfaceNode.getDisplayName()] = interfaceNode.implementByPointer[structNode.getIdentity()]
+		}
+		return result
+	}
+	if typeNode := n.getMatchedType(baseName); typeNode != nil {
+		for _, interfaceNode := range typeNode.implementInterfaces {
+			result[interfaceNode.getDisplayName()] = interfaceNode.implementByPointer[typeNode.getIdentity()]
+		}
+	}
+	return result
+}
+
 func (n *NodeManager) getMatchedFunction(baseName string) *FunctionNode {
-	var node *FunctionNode
-	elems := strings.Split(baseName, "/")
-	if len(elems) != 3 {
-		return node
+	packageName, elems := splitBaseName(baseName, 2)
+	if elems == nil {
+		return nil
 	}
-	packageName, receiver, name := elems[0], elems[1], elems[2]
-	functionNodes, ok := n.allFunctions[name]
-	if !ok {
-		return node
+	receiver, name := elems[0], elems[1]
+	// 带有声明位置的init只匹配对应的声明，函数字面量的名称中已经包含了位置
+	declName := name
+	if i := strings.Index(name, "@"); i >= 0 && !strings.Contains(name, "$") {
+		declName = name[:i]
 	}
 
-	for _, functionNode := range functionNodes {
-		if functionNode.fileNode.packageName == packageName && functionNode.receiver == receiver {
-			node = functionNode
-			break
+	functionNodes := make(map[*FileNode]*FunctionNode, 0)
+	fileNodes := make([]*FileNode, 0)
+	for _, functionNode := range n.allFunctions[declName] {
+		if functionNode.receiver == receiver && (functionNode.name == name || functionNode.getLocalName() == name) {
+			functionNodes[functionNode.fileNode] = functionNode
+			fileNodes = append(fileNodes, functionNode.fileNode)
 		}
 	}
-	return node
+	return functionNodes[n.matchPackage(fileNodes, packageName)]
 }
 
 func (n *NodeManager) GetFunctionCallerCodeSnippet(baseName string) map[string]string {
@@ -76,22 +238,19 @@ func (n *NodeManager) GetFunctionCalleeCodeSnippet(baseName string) map[string]s
 	return node.GetCalleeCodeSnippet(result)
 }
 
-
-
 // map[module]map[struct][]functions
 func (n *NodeManager) Relation() map[string]map[string][]string {
-	projectInternalRelation := make(map[string]map[string][]string , 0)
+	projectInternalRelation := make(map[string]map[string][]string, 0)
 
 	for _, nodes := range n.allFunctions {
 		for _, node := range nodes {
-			elems := strings.Split(strings.Trim(node.getIdentity(), "\""), "/")
-			if len(elems) != 3 {
-				Log.Sugar().Errorf("invalid identity:%+v", *node)
+			// 函数字面量不算在结构体的方法中
+			if node.literal != nil {
 				continue
 			}
-			moduleName := elems[0]
-			structName := elems[1]
-			functionName := elems[2]
+			moduleName := node.fileNode.getPackageDisplayName()
+			structName := node.receiver
+			functionName := node.name
 			if _, ok := projectInternalRelation[moduleName]; !ok {
 				projectInternalRelation[moduleName] = make(map[string][]string, 0)
 			}
@@ -106,14 +265,8 @@ func (n *NodeManager) Relation() map[string]map[string][]string {
 	}
 
 	for _, node := range n.allStructs {
-		elems := strings.Split(strings.Trim(node.getIdentity(), "\""), "/")
-		if len(elems) != 2 {
-			Log.Sugar().Errorf("invalid identity:%s", node.getIdentity())
-			continue
-		}
-
-		moduleName := elems[0]
-		structName := elems[1]
+		moduleName := node.fileNode.getPackageDisplayName()
+		structName := node.name
 
 		if _, ok := projectInternalRelation[moduleName]; !ok {
 			projectInternalRelation[moduleName] = make(map[string][]string, 0)
@@ -125,14 +278,8 @@ func (n *NodeManager) Relation() map[string]map[string][]string {
 	}
 
 	for _, node := range n.allInterfaces {
-		elems := strings.Split(strings.Trim(node.getIdentity(), "\""), "/")
-		if len(elems) != 2 {
-			Log.Sugar().Errorf("invalid identity:%s", node.getIdentity())
-			continue
-		}
-
-		moduleName := elems[0]
-		interfaceName := elems[1]
+		moduleName := node.fileNode.getPackageDisplayName()
+		interfaceName := node.name
 
 		if _, ok := projectInternalRelation[moduleName]; !ok {
 			projectInternalRelation[moduleName] = make(map[string][]string, 0)
@@ -143,6 +290,20 @@ func (n *NodeManager) Relation() map[string]map[string][]string {
 		}
 	}
 
+	// 没有方法的具名类型也要出现，方法已经按接收者归到类型下面
+	for _, node := range n.allTypes {
+		moduleName := node.fileNode.getPackageDisplayName()
+		typeName := node.name
+
+		if _, ok := projectInternalRelation[moduleName]; !ok {
+			projectInternalRelation[moduleName] = make(map[string][]string, 0)
+		}
+
+		if _, ok := projectInternalRelation[moduleName][typeName]; !ok {
+			projectInternalRelation[moduleName][typeName] = make([]string, 0)
+		}
+	}
+
 	return projectInternalRelation
 }
 
@@ -159,13 +320,12 @@ func (n *NodeManager) getStructReceiverLabel(receiver string) string {
 
 func (n *NodeManager) DrawStruct(baseStruct string, count int) {
 
-	structNode, ok := n.allStructs[baseStruct]
-	if !ok {
+	structNode := n.getMatchedStruct(baseStruct)
+	if structNode == nil {
 		return
 	}
 
-	content := bytes.NewBuffer([]byte{})
-	content.WriteString("digraph gph {")
+	content := n.newGraph()
 
 	// 绘制base struct节点
 	record := make(map[string]bool, 0)
@@ -176,17 +336,86 @@ func (n *NodeManager) DrawStruct(baseStruct string, count int) {
 
 	content.WriteString("}")
 
-	os.MkdirAll(n.getBaseDir(), 0755)
-	baseStruct = strings.Trim(baseStruct, "\"")
+	baseStruct = strings.Trim(structNode.getDisplayName(), "\"")
 	baseStruct = strings.ReplaceAll(baseStruct, "/", "_")
-	if err := ioutil.WriteFile(fmt.Sprintf(n.getBaseDir()+"/struct_%s.dot", baseStruct), content.Bytes(), 0644); err == nil {
-		if err = sh.Command("/bin/bash", "-c", fmt.Sprintf("dot %s/struct_%s.dot -o %s/struct_%s.png -Tpng", n.getBaseDir(), baseStruct, n.getBaseDir(), baseStruct)).Run(); err == nil {
+	n.writeGraph("struct_"+baseStruct, content)
+}
+
+// 用tag中的名称绘制结构体，比如json或者db，方便和序列化格式以及表结构对比
+func (n *NodeManager) DrawStructByTag(baseStruct string, tag string, count int) {
+	structNode := n.getMatchedStruct(baseStruct)
+	if structNode == nil {
+		return
+	}
+
+	content := n.newGraph()
+
+	record := make(map[string]bool, 0)
+	structNode.DrawTagNode(content, record, count, tag)
+
+	record = make(map[string]bool, 0)
+	structNode.DrawRelation(content, record, count)
+
+	content.WriteString("}")
+
+	baseStruct = strings.Trim(structNode.getDisplayName(), "\"")
+	baseStruct = strings.ReplaceAll(baseStruct, "/", "_")
+	n.writeGraph("struct_"+tag+"_"+baseStruct, content)
+}
+
+// 绘制具名类型，它的底层类型，方法以及实现了的接口
+func (n *NodeManager) DrawType(baseType string, count int) {
+	typeNode := n.getMatchedType(baseType)
+	if typeNode == nil {
+		return
+	}
+
+	content := n.newGraph()
+
+	record := make(map[string]bool, 0)
+	typeNode.DrawNode(content, record, count)
+	for _, method := range typeNode.getMethods() {
+		content.WriteString(fmt.Sprintf("%s [label=%s, %s];\n", method.getIdentity(), method.getLabel(), method.getNodeAttributes()))
+	}
+	for _, interfaceNode := range typeNode.implementInterfaces {
+		interfaceNode.DrawNode(content, record)
+	}
+
+	record = make(map[string]bool, 0)
+	typeNode.DrawRelation(content, record, count)
+	for _, method := range typeNode.getMethods() {
+		content.WriteString(fmt.Sprintf("%s -> %s [style=\"dashed\", arrowhead=\"none\", label=\"method\"];\n", typeNode.getIdentity(), method.getIdentity()))
+	}
+	for _, interfaceNode := range typeNode.implementInterfaces {
+		interfaceNode.drawImplementEdge(content, typeNode.getIdentity())
+	}
+
+	content.WriteString("}")
+
+	baseType = strings.Trim(typeNode.getDisplayName(), "\"")
+	baseType = strings.ReplaceAll(baseType, "/", "_")
+	n.writeGraph("type_"+baseType, content)
+}
+
+// 图的开头，标注解析时使用的构建环境
+func (n *NodeManager) newGraph() *bytes.Buffer {
+	content := bytes.NewBuffer([]byte{})
+	content.WriteString("digraph gph {")
+	content.WriteString(fmt.Sprintf("label=\"%s\";labelloc=\"t\";\n", n.config.describeBuildContext()))
+	return content
+}
+
+// 写入dot文件并生成对应的png
+func (n *NodeManager) writeGraph(name string, content *bytes.Buffer) {
+	os.MkdirAll(n.getBaseDir(), 0755)
+	if err := ioutil.WriteFile(fmt.Sprintf("%s/%s.dot", n.getBaseDir(), name), content.Bytes(), 0644); err == nil {
+		if err = sh.Command("/bin/bash", "-c", fmt.Sprintf("dot %s/%s.dot -o %s/%s.png -Tpng", n.getBaseDir(), name, n.getBaseDir(), name)).Run(); err == nil {
 			Log.Sugar().Infof("draw success!")
 		} else {
-			Log.Sugar().Errorf("draw visualization.dot fail, error:%s", err.Error())
+			Log.Sugar().Errorf("draw %s.dot fail, error:%s", name, err.Error())
 		}
 	} else {
-		Log.Sugar().Errorf("write visualization.dot fail, error:%s", err.Error())
+		Log.Sugar().Errorf("write %s.dot fail, error:%s", name, err.Error())
 	}
 }
 
@@ -207,8 +436,7 @@ func (n *NodeManager) DrawCallerFunction(baseFunction string, count int) {
 		return
 	}
 
-	content := bytes.NewBuffer([]byte{})
-	content.WriteString("digraph gph {")
+	content := n.newGraph()
 
 	record := make(map[string]bool, 0)
 	node.DrawCallerNode(content, record, count)
@@ -218,19 +446,9 @@ func (n *NodeManager) DrawCallerFunction(baseFunction string, count int) {
 
 	content.WriteString("}")
 
-	os.MkdirAll(n.getBaseDir(), 0755)
-
+	baseFunction = strings.Trim(node.getDisplayName(), "\"")
 	baseFunction = strings.ReplaceAll(baseFunction, "/", "_")
-	if err := ioutil.WriteFile(fmt.Sprintf(n.getBaseDir()+"/function_caller_%s.dot", baseFunction), content.Bytes(), 0644); err == nil {
-		if err = sh.Command("/bin/bash", "-c", fmt.Sprintf("dot %s/function_caller_%s.dot -o %s/function_caller_%s.png -Tpng", n.getBaseDir(), baseFunction,
-			n.getBaseDir(), baseFunction)).Run(); err == nil {
-			Log.Sugar().Infof("draw success!")
-		} else {
-			Log.Sugar().Errorf("draw visualization.dot fail, error:%s", err.Error())
-		}
-	} else {
-		Log.Sugar().Errorf("write visualization.dot fail, error:%s", err.Error())
-	}
+	n.writeGraph("function_caller_"+baseFunction, content)
 }
 
 func (n *NodeManager) DrawCalleeFunction(baseFunction string, count int) {
@@ -239,8 +457,7 @@ func (n *NodeManager) DrawCalleeFunction(baseFunction string, count int) {
 		return
 	}
 
-	content := bytes.NewBuffer([]byte{})
-	content.WriteString("digraph gph {")
+	content := n.newGraph()
 
 	record := make(map[string]bool, 0)
 	node.DrawCalleeNode(content, record, count)
@@ -250,22 +467,36 @@ func (n *NodeManager) DrawCalleeFunction(baseFunction string, count int) {
 
 	content.WriteString("}")
 
-	os.MkdirAll(n.getBaseDir(), 0755)
-
+	baseFunction = strings.Trim(node.getDisplayName(), "\"")
 	baseFunction = strings.ReplaceAll(baseFunction, "/", "_")
-	if err := ioutil.WriteFile(fmt.Sprintf(n.getBaseDir()+"/function_callee_%s.dot", baseFunction), content.Bytes(), 0644); err == nil {
-		if err = sh.Command("/bin/bash", "-c", fmt.Sprintf("dot %s/function_callee_%s.dot -o %s/function_callee_%s.png -Tpng", n.getBaseDir(), baseFunction,
-			n.getBaseDir(), baseFunction)).Run(); err == nil {
-			Log.Sugar().Infof("draw success!")
-		} else {
-			Log.Sugar().Errorf("draw visualization.dot fail, error:%s", err.Error())
-		}
-	} else {
-		Log.Sugar().Errorf("write visualization.dot fail, error:%s", err.Error())
+	n.writeGraph("function_callee_"+baseFunction, content)
+}
+
+func (n *NodeManager) DrawInterface(baseInterface string, count int) {
+	interfaceNode := n.getMatchedInterface(baseInterface)
+	if interfaceNode == nil {
+		return
 	}
+
+	content := n.newGraph()
+
+	record := make(map[string]bool, 0)
+	interfaceNode.DrawImplementNode(content, record, count)
+
+	record = make(map[string]bool, 0)
+	interfaceNode.DrawImplementRelation(content, record, count)
+
+	content.WriteString("}")
+
+	baseInterface = strings.Trim(interfaceNode.getDisplayName(), "\"")
+	baseInterface = strings.ReplaceAll(baseInterface, "/", "_")
+	n.writeGraph("interface_"+baseInterface, content)
 }
 
 func (n *NodeManager) mergeInterfaceImplement() {
+	for _, interfaceNode := range n.allInterfaces {
+		interfaceNode.mergeEmbedded()
+	}
 
 	// package
 	for _, package_ := range n.packages {
@@ -273,7 +504,7 @@ func (n *NodeManager) mergeInterfaceImplement() {
 		for _, filenode := range package_ {
 			// interface
 			for _, interfaceNode := range filenode.interfaceNodes {
-				interfaceNode.mergeImplement(n.functionNames)
+				interfaceNode.mergeImplement(n.allStructs, n.allTypes)
 			}
 		}
 	}
@@ -289,30 +520,29 @@ func (n *NodeManager) mergeFunction() {
 }
 
 func (n *NodeManager) mergeStruct() {
-	// package
+	// 先建立类型对象和节点的对应关系，字段的类型依赖这些对应关系
 	for _, package_ := range n.packages {
-		// file
 		for _, filenode := range package_ {
-			// structs
 			for _, structNode := range filenode.structNodes {
-				if _, ok := n.structTypes[filenode.packageName]; ok == false {
-					n.structTypes[filenode.packageName] = make(map[string]*StructNode, 0)
+				structNode.object = n.getTypeName(structNode.typeSpec)
+				n.allStructs[structNode.getIdentity()] = structNode
+				if structNode.object != nil {
+					n.structObjects[structNode.object] = structNode
 				}
-				n.structTypes[filenode.packageName][structNode.name] = structNode
 			}
-		}
-	}
-
-	// package
-	for _, package_ := range n.packages {
-		// file
-		for _, filenode := range package_ {
-			// functions
 			for _, interfaceNode := range filenode.interfaceNodes {
-				if _, ok := n.interfaceNames[filenode.packageName]; ok == false {
-					n.interfaceNames[filenode.packageName] = make(map[string]*InterfaceNode, 0)
+				interfaceNode.object = n.getTypeName(interfaceNode.typeSpec)
+				n.allInterfaces[interfaceNode.getIdentity()] = interfaceNode
+				if interfaceNode.object != nil {
+					n.interfaceObjects[interfaceNode.object] = interfaceNode
+				}
+			}
+			for _, typeNode := range filenode.typeNodes {
+				typeNode.object = n.getTypeName(typeNode.typeSpec)
+				n.allTypes[typeNode.getIdentity()] = typeNode
+				if typeNode.object != nil {
+					n.typeObjects[typeNode.object] = typeNode
 				}
-				n.interfaceNames[filenode.packageName][interfaceNode.name] = interfaceNode
 			}
 		}
 	}
@@ -320,57 +550,135 @@ func (n *NodeManager) mergeStruct() {
 	// 归并
 	for _, package_ := range n.packages {
 		for _, filenode := range package_ {
-			filenode.MergeStruct(n.structTypes, n.interfaceNames)
+			filenode.MergeStruct()
 		}
 	}
+}
 
+func (n *NodeManager) mergeImports() {
 	for _, package_ := range n.packages {
 		for _, filenode := range package_ {
-			for _, structNode := range filenode.structNodes {
-				n.allStructs[structNode.getIdentity()] = structNode
-			}
+			filenode.mergeImports()
 		}
 	}
+}
 
-	for _, package_ := range n.packages {
-		for _, filenode := range package_ {
-			for _, interfaceNode := range filenode.interfaceNodes {
-				n.allInterfaces[interfaceNode.getIdentity()] = interfaceNode
-			}
+func (n *NodeManager) mergeCall() {
+	for _, nodes := range n.allFunctions {
+		for _, node := range nodes {
+			node.mergeCallee()
 		}
 	}
-
 }
 
+// 全部文件解析完之后归并出各种关系，只能执行一次，重复调用会被忽略
 func (n *NodeManager) Merge() {
+	if n.merged {
+		Log.Sugar().Warnf("nodes are already merged, ignore this call")
+		return
+	}
+	n.merged = true
+
+	// 类型检查
+	n.mergeTypes()
+
+	// 导入的包名
+	n.mergeImports()
+
 	// 输出结构体依赖
 	n.mergeStruct()
 
 	// 输出函数依赖
 	n.mergeFunction()
 
+	// 根据类型信息解析调用关系
+	n.mergeCall()
+
+	// 全局变量以及读写它们的函数
+	n.mergeGlobals()
+
 	//  查看方法的实现
 	n.mergeInterfaceImplement()
+	n.mergeImplementMethods()
+
+	// 递归调用以及相互包含的类型
+	n.mergeCycles()
+}
+
+// 记录没有解析的文件以及原因，方便排查
+func (n *NodeManager) Skip(file string, reason string) {
+	Log.Sugar().Debugf("skip file:%s, reason:%s", file, reason)
+	n.skippedFiles[file] = reason
+}
+
+func (n *NodeManager) GetParseErrors() ParseErrors {
+	return append(ParseErrors{}, n.parseErrors...)
+}
+
+// 严格模式下有文件解析失败时返回全部文件的解析错误，宽松模式下总是返回nil，错误可以通过GetParseErrors获取
+func (n *NodeManager) CheckParseErrors() error {
+	if !n.config.Strict || len(n.parseErrors) == 0 {
+		return nil
+	}
+	return n.GetParseErrors()
+}
+
+func (n *NodeManager) GetSkippedFiles() map[string]string {
+	result := make(map[string]string, 0)
+	for file, reason := range n.skippedFiles {
+		result[file] = reason
+	}
+	return result
 }
 
-// 解析源文件，解析出对应的结构体，接口，函数
+// 解析源文件，解析出对应的结构体，接口，函数，需要在Merge之前调用
 func (n *NodeManager) Inspect(file string) error {
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
-	if err != nil {
-		Log.Sugar().Errorf("can't parse file:%s, error:%s", file, err.Error())
-		return err
+	if n.merged {
+		return fmt.Errorf("can't inspect file:%s after merge", file)
+	}
+	// 有语法错误时仍然使用解析出的部分ast，能恢复的声明照常记录
+	f, parseErr := parser.ParseFile(n.fset, file, nil, parser.ParseComments|parser.AllErrors)
+	var fileErrors ParseErrors
+	if parseErr != nil {
+		Log.Sugar().Errorf("can't parse file:%s, error:%s", file, parseErr.Error())
+		fileErrors = newParseErrors(file, parseErr)
+		n.parseErrors = append(n.parseErrors, fileErrors...)
+		// 严格模式下不使用不完整的ast，连package声明都没有解析出来时也无法使用
+		if n.config.Strict || f == nil || f.Package == token.NoPos || f.Name == nil || f.Name.Name == "_" {
+			return fileErrors
+		}
 	}
 
 	content, err := ioutil.ReadFile(file)
 	if err != nil {
 		Log.Sugar().Errorf("can't read file:%s content, error:%s", file, err.Error())
-		return err
+		if fileErrors == nil {
+			fileErrors = newParseErrors(file, err)
+			n.parseErrors = append(n.parseErrors, fileErrors...)
+		}
+		return fileErrors
 	}
 
+	// 所有文件共用一个FileSet，位置需要减去文件的base才是文件内偏移
+	base := token.Pos(n.fset.File(f.Package).Base())
+
 	fileParser := NewFileNode(n, file, f.Name.Name)
+	fileParser.astFile = f
+	fileParser.packagePath = n.getImportPath(filepath.Dir(file))
+	// 外部测试包是一个单独的包
+	if fileParser.isTest && strings.HasSuffix(f.Name.Name, "_test") {
+		fileParser.packagePath += "_test"
+	}
+
+	typeDocs := getTypeDocs(f)
+	typeDecls := getTypeDecls(f)
 
+	// 函数中的局部类型不算
 	structParser := func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return false
+		}
 		t, ok := n.(*ast.TypeSpec)
 		if !ok {
 			return true
@@ -386,33 +694,53 @@ func (n *NodeManager) Inspect(file string) error {
 		}
 
 		fields := make(map[string]string, 0)
+		embeddedFields := make(map[string]bool, 0)
+		tags := make(map[string]reflect.StructTag, 0)
+		fieldTypes := make(map[string]ast.Expr, 0)
+		fieldNames := make([]string, 0)
 		for _, v := range x.Fields.List {
 			typeExpr := v.Type
-			start := typeExpr.Pos() - 1
-			end := typeExpr.End() - 1
-			typeInSource := string(content)[start:end]
+			typeInSource := getNodeSource(content, base, typeExpr, 0)
 			// 去掉无用的空格
 			typeInSource = strings.Trim(typeInSource, " ")
+			tag := getStructTag(v.Tag)
 			if len(v.Names) > 0 {
-				// name -> type
-				fields[v.Names[0].Name] = typeInSource
+				// name -> type，一次声明多个字段时每个名字都是一个字段
+				for _, name := range v.Names {
+					fields[name.Name] = typeInSource
+					fieldTypes[name.Name] = typeExpr
+					tags[name.Name] = tag
+					fieldNames = append(fieldNames, name.Name)
+				}
 			} else {
 				// 匿名成员变量
 				fields[typeInSource] = typeInSource
+				fieldTypes[typeInSource] = typeExpr
+				embeddedFields[typeInSource] = true
+				tags[typeInSource] = tag
+				fieldNames = append(fieldNames, typeInSource)
 			}
 		}
 
-		i := x.Pos()
-		for content[i] != '\n' && i >= 0 {
-			i--
-		}
-		structNode := NewStructNode(fileParser, t.Name.Name, string(content)[i+1:x.End()], fields)
+		structNode := NewStructNode(fileParser, t.Name.Name, getTypeSource(content, base, typeDecls[t], t), fields)
 
+		structNode.typeSpec = t
+		structNode.position = fileParser.nodeManager.fset.Position(t.Pos())
+		structNode.doc = typeDocs[t]
+		structNode.embeddedFields = embeddedFields
+		structNode.tags = tags
+		structNode.fieldTypes = fieldTypes
+		structNode.fieldNames = fieldNames
+		structNode.typeParams = getTypeParams(t.TypeParams)
 		fileParser.structNodes[structNode.name] = structNode
 		return true
 	}
 
 	interfaceParser := func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return false
+		}
 		t, ok := n.(*ast.TypeSpec)
 		if !ok {
 			return true
@@ -427,23 +755,38 @@ func (n *NodeManager) Inspect(file string) error {
 			return true
 		}
 
+		// 方法以及嵌入的接口，类型约束
 		methods := make(map[string]string, 0)
-		if !x.Incomplete {
-			functions := strings.Split(string(content[x.Methods.Opening:x.Methods.Closing]), "\n")
-			for _, function := range functions {
-				function = strings.Trim(function, "\t")
-				function = strings.TrimSpace(function)
-				if strings.Contains(function, "(") && strings.Contains(function, ")") && !strings.HasPrefix(function, "//") && !strings.HasPrefix(function, "*") && !strings.HasPrefix(function, "/*"){
-					name := strings.Split(function, "(")[0]
-					methods[name] = function
-				}
+		methodNames := make([]string, 0)
+		methodPositions := make(map[string]token.Pos, 0)
+		methodDocs := make(map[string]string, 0)
+		embeds := make([]ast.Expr, 0)
+		for _, field := range x.Methods.List {
+			if len(field.Names) == 0 {
+				embeds = append(embeds, field.Type)
+				continue
+			}
+			for _, name := range field.Names {
+				methods[name.Name] = name.Name + strings.TrimPrefix(types.ExprString(field.Type), "func")
+				methodNames = append(methodNames, name.Name)
+				methodPositions[name.Name] = name.Pos()
+				methodDocs[name.Name] = field.Doc.Text()
 			}
 		}
-		interfaceNode := NewInterfaceNode(fileParser, t.Name.Name, string(content[t.Pos()-1:t.End()]), methods)
+		source := getTypeSource(content, base, typeDecls[t], t)
+		interfaceNode := NewInterfaceNode(fileParser, t.Name.Name, source, methods)
+		interfaceNode.typeSpec = t
+		interfaceNode.position = fileParser.nodeManager.fset.Position(t.Pos())
+		interfaceNode.doc = typeDocs[t]
+		interfaceNode.typeParams = getTypeParams(t.TypeParams)
+		interfaceNode.methodNames = methodNames
+		interfaceNode.embeds = embeds
 		fileParser.interfaceNodes[t.Name.Name] = interfaceNode
 
-		for name, _ := range methods {
-			functionNode := NewFunctionNode(fileParser, name, t.Name.Name, string(content[t.Pos()-1:t.End()]), []string{}, []string{})
+		for _, name := range methodNames {
+			functionNode := NewFunctionNode(fileParser, name, t.Name.Name, source, []string{}, []string{})
+			functionNode.position = fileParser.nodeManager.fset.Position(methodPositions[name])
+			functionNode.doc = methodDocs[name]
 			if _, ok := fileParser.functionNodes[functionNode.name]; ok == false {
 				fileParser.functionNodes[functionNode.name] = make([]*FunctionNode, 0)
 			}
@@ -453,20 +796,41 @@ func (n *NodeManager) Inspect(file string) error {
 		return true
 	}
 
+	// 结构体以及接口以外的具名类型以及类型别名，函数中的局部类型不算
+	typeParser := func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return false
+		}
+		t, ok := n.(*ast.TypeSpec)
+		if !ok || t.Type == nil {
+			return true
+		}
+		switch t.Type.(type) {
+		case *ast.StructType, *ast.InterfaceType:
+			return true
+		}
+
+		typeNode := NewTypeNode(fileParser, t.Name.Name, getTypeSource(content, base, typeDecls[t], t), t.Assign.IsValid())
+		typeNode.typeSpec = t
+		typeNode.position = fileParser.nodeManager.fset.Position(t.Pos())
+		typeNode.doc = typeDocs[t]
+		typeNode.typeParams = getTypeParams(t.TypeParams)
+		fileParser.typeNodes[typeNode.name] = typeNode
+		return true
+	}
+
 	functionParser := func(n ast.Node) bool {
 		x, ok := n.(*ast.FuncDecl)
 		if !ok {
 			return true
 		}
 		receiver := ""
+		typeParams := getTypeParams(x.Type.TypeParams)
 
-		if x.Recv != nil {
-			receiver = string(content)[int(x.Recv.Opening) : int(x.Recv.Closing)-1]
-			elems := strings.Split(receiver, " ")
-			if len(elems) == 2 {
-				receiver = elems[1]
-				receiver = strings.TrimLeft(receiver, "*")
-			}
+		// 泛型方法的类型参数来自接收者
+		if x.Recv != nil && len(x.Recv.List) > 0 {
+			receiver, typeParams = getReceiverType(x.Recv.List[0].Type)
 		}
 		parameters := make([]string, 0)
 		returns := make([]string, 0)
@@ -474,7 +838,7 @@ func (n *NodeManager) Inspect(file string) error {
 		// 设置对应的参数和返回值
 		if x.Type.Params != nil && x.Type.Params.List != nil {
 			for _, param := range x.Type.Params.List {
-				paramType := string(content)[int(param.Type.Pos())-1 : int(param.Type.End())-1]
+				paramType := getNodeSource(content, base, param.Type, 0)
 				paramType = strings.Trim(paramType, " ")
 				paramType = strings.TrimLeft(paramType, "*")
 				parameters = append(parameters, paramType)
@@ -483,18 +847,29 @@ func (n *NodeManager) Inspect(file string) error {
 
 		if x.Type.Results != nil && x.Type.Results.List != nil {
 			for _, result := range x.Type.Results.List {
-				resultType := string(content)[int(result.Type.Pos())-1 : int(result.Type.End())]
+				resultType := getNodeSource(content, base, result.Type, 1)
 				resultType = strings.Trim(resultType, " ")
 				resultType = strings.TrimLeft(resultType, "*")
 				returns = append(returns, resultType)
 			}
 		}
 
-		functionNode := NewFunctionNode(fileParser, x.Name.Name, receiver, string(content[x.Pos()-1:x.End()]), parameters, returns)
+		functionNode := NewFunctionNode(fileParser, x.Name.Name, receiver, getNodeSource(content, base, x, 1), parameters, returns)
+		functionNode.decl = x
+		functionNode.position = fileParser.nodeManager.fset.Position(x.Pos())
+		functionNode.doc = x.Doc.Text()
+		functionNode.typeParams = typeParams
+		if isRepeatableFunction(x) {
+			functionNode.site = fmt.Sprintf("%s:%d:%d", filepath.Base(functionNode.position.Filename), functionNode.position.Line, functionNode.position.Column)
+		}
 		if _, ok := fileParser.functionNodes[functionNode.name]; ok == false {
 			fileParser.functionNodes[functionNode.name] = make([]*FunctionNode, 0)
 		}
 		fileParser.functionNodes[functionNode.name] = append(fileParser.functionNodes[functionNode.name], functionNode)
+
+		for _, literal := range functionNode.parseLiterals(content, base) {
+			fileParser.functionNodes[literal.name] = append(fileParser.functionNodes[literal.name], literal)
+		}
 		return true
 	}
 
@@ -503,18 +878,16 @@ func (n *NodeManager) Inspect(file string) error {
 		if !ok {
 			return true
 		}
+		importPath, err := strconv.Unquote(x.Path.Value)
+		if err != nil {
+			return true
+		}
+		// 类型检查之前只能根据导入路径推测包名，Merge时会替换成真实的包名
+		name := guessPackageName(importPath)
 		if x.Name != nil {
-			fileParser.importers[x.Name.Name] = x.Path.Value
-		} else {
-			elems := strings.Split("/", x.Path.Value)
-			var name string
-			if len(elems) == 1 {
-				name = x.Path.Value
-			} else {
-				name = elems[len(elems)-1]
-			}
-			fileParser.importers[name] = x.Path.Value
+			name = x.Name.Name
 		}
+		fileParser.addImport(name, importPath)
 		return true
 	}
 
@@ -522,15 +895,29 @@ func (n *NodeManager) Inspect(file string) error {
 
 	ast.Inspect(f, structParser)
 
+	ast.Inspect(f, typeParser)
+
 	ast.Inspect(f, functionParser)
 
 	ast.Inspect(f, importParser)
 
-	if _, ok := n.packages[f.Name.Name]; !ok {
-		n.packages[f.Name.Name] = make([]*FileNode, 0)
+	for _, globalNode := range parseGlobals(fileParser, f, content, base) {
+		fileParser.globalNodes[globalNode.name] = globalNode
+	}
+
+	if _, ok := n.packages[fileParser.packagePath]; !ok {
+		n.packages[fileParser.packagePath] = make([]*FileNode, 0)
 	}
 
-	n.packages[f.Name.Name] = append(n.packages[f.Name.Name], fileParser)
+	n.packages[fileParser.packagePath] = append(n.packages[fileParser.packagePath], fileParser)
 
+	if _, ok := n.packageNames[fileParser.packageName]; !ok {
+		n.packageNames[fileParser.packageName] = make(map[string]bool, 0)
+	}
+	n.packageNames[fileParser.packageName][fileParser.packagePath] = true
+
+	if fileErrors != nil {
+		return fileErrors
+	}
 	return nil
 }
@@ -0,0 +1,90 @@
+package fileparser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLocalTypes(t *testing.T) {
+	nodeManager := parseSource(t, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"main.go": `package main
+
+type Config struct{ Global string }
+
+type closer interface{ Close() error }
+
+type ID int
+
+func open() {
+	type Config struct{ Local int }
+	type closer interface{ Close() }
+	type ID string
+	_ = Config{}
+}
+
+func shut() {
+	type closer interface{ Close() }
+	f := func() {
+		type Config struct{ Inner bool }
+	}
+	f()
+}
+
+func main() {}
+`,
+	})
+
+	snippet := nodeManager.GetStructCodeSnippet("main/Config")[`"main/Config"`]
+	if !strings.Contains(snippet, "Global") || strings.Contains(snippet, "Local int") || strings.Contains(snippet, "Inner bool") {
+		t.Errorf("GetStructCodeSnippet(main/Config) = %q, want the package level struct", snippet)
+	}
+	if len(nodeManager.allStructs) != 1 || len(nodeManager.allInterfaces) != 1 || len(nodeManager.allTypes) != 1 {
+		t.Errorf("structs %d, interfaces %d, types %d, want 1 of each", len(nodeManager.allStructs), len(nodeManager.allInterfaces), len(nodeManager.allTypes))
+	}
+	if methods := nodeManager.allFunctions["Close"]; len(methods) != 1 || methods[0].object == nil || methods[0].object.Type().String() != "func() error" {
+		t.Errorf("interface methods Close = %v, want the method of the package level interface", methods)
+	}
+}
+
+func TestTypeSnippets(t *testing.T) {
+	nodeManager := parseSource(t, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"main.go": `package main
+
+type Config struct{ Name string }
+
+type Handler func(name string) error
+
+type (
+	Store interface{ Save() }
+	ID    int
+	Alias = Config
+)
+
+func main() {}
+`,
+	})
+
+	contents := make(map[string]string, 0)
+	for identity, structNode := range nodeManager.allStructs {
+		contents[identity] = structNode.content
+	}
+	for identity, interfaceNode := range nodeManager.allInterfaces {
+		contents[identity] = interfaceNode.content
+	}
+	for identity, typeNode := range nodeManager.allTypes {
+		contents[identity] = typeNode.content
+	}
+	want := map[string]string{
+		`"example.com/app:Config"`:  "type Config struct{ Name string }\n",
+		`"example.com/app:Handler"`: "type Handler func(name string) error\n",
+		`"example.com/app:Store"`:   "type Store interface{ Save() }\n",
+		`"example.com/app:ID"`:      "type ID    int\n",
+		`"example.com/app:Alias"`:   "type Alias = Config\n",
+	}
+	if !reflect.DeepEqual(contents, want) {
+		t.Errorf("type contents = %q, want %q", contents, want)
+	}
+}
@@ -0,0 +1,149 @@
+package fileparser
+
+import (
+	"fmt"
+	"go/build"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 是否是标准库，项目本身以及go.mod中依赖的模块不是标准库，其余的包在GOROOT中查找，
+// 只有找不到时才根据导入路径第一段是否带域名判断
+func (n *NodeManager) isStdlib(importPath string) bool {
+	if stdlib, ok := n.stdlibPackages[importPath]; ok {
+		return stdlib
+	}
+
+	stdlib := false
+	modules := append(readRequiredModules(filepath.Join(n.moduleDir, "go.mod")), n.modulePath)
+	if getModule(importPath, modules) == importPath && !containsString(modules, importPath) {
+		if buildPackage, err := n.config.BuildContext.Import(importPath, n.moduleDir, build.FindOnly); err == nil {
+			stdlib = buildPackage.Goroot
+		} else {
+			stdlib = !strings.Contains(strings.Split(importPath, "/")[0], ".")
+		}
+	}
+	n.stdlibPackages[importPath] = stdlib
+	return stdlib
+}
+
+func containsString(elems []string, target string) bool {
+	for _, elem := range elems {
+		if elem == target {
+			return true
+		}
+	}
+	return false
+}
+
+// 第三方包归到go.mod中声明的模块，找不到时使用包本身
+func getModule(importPath string, modules []string) string {
+	result := importPath
+	length := 0
+	for _, module := range modules {
+		if (importPath == module || strings.HasPrefix(importPath, module+"/")) && len(module) > length {
+			result = module
+			length = len(module)
+		}
+	}
+	return result
+}
+
+// 包之间的导入关系，value为导入的文件数
+func (n *NodeManager) getPackageImports(thirdParty bool, stdlib bool) map[string]map[string]int {
+	modules := readRequiredModules(filepath.Join(n.moduleDir, "go.mod"))
+	imports := make(map[string]map[string]int, 0)
+	for packagePath, fileNodes := range n.packages {
+		imports[packagePath] = make(map[string]int, 0)
+		for _, fileNode := range fileNodes {
+			for _, spec := range fileNode.astFile.Imports {
+				importPath, err := strconv.Unquote(spec.Path.Value)
+				if err != nil {
+					continue
+				}
+				if _, ok := n.packages[importPath]; !ok {
+					if n.isStdlib(importPath) {
+						if !stdlib {
+							continue
+						}
+					} else {
+						if !thirdParty {
+							continue
+						}
+						importPath = getModule(importPath, modules)
+					}
+				}
+				imports[packagePath][importPath]++
+			}
+		}
+	}
+	return imports
+}
+
+// 项目内包的导入关系图，边的粗细表示导入的文件数，导入环标红
+func (n *NodeManager) DrawPackages(thirdParty bool, stdlib bool) {
+	imports := n.getPackageImports(thirdParty, stdlib)
+
+	packages := make([]string, 0)
+	internalEdges := make(map[string][]string, 0)
+	for packagePath, targets := range imports {
+		packages = append(packages, packagePath)
+		for target := range targets {
+			if _, ok := n.packages[target]; ok {
+				internalEdges[packagePath] = append(internalEdges[packagePath], target)
+			}
+		}
+	}
+	sort.Strings(packages)
+
+	cycleOf := make(map[string]int, 0)
+	for i, cycle := range getCycles(packages, internalEdges) {
+		for _, packagePath := range cycle {
+			cycleOf[packagePath] = i + 1
+		}
+	}
+
+	content := n.newGraph()
+	record := make(map[string]bool, 0)
+	for _, packagePath := range packages {
+		attributes := fmt.Sprintf("label=\"%s\\nfiles: %d\", shape=\"box\"", packagePath, len(n.packages[packagePath]))
+		if cycleOf[packagePath] > 0 {
+			attributes += ", color=\"red\""
+		}
+		content.WriteString(fmt.Sprintf("\"%s\" [%s];\n", packagePath, attributes))
+		record[packagePath] = true
+	}
+
+	for _, packagePath := range packages {
+		targets := make([]string, 0)
+		for target := range imports[packagePath] {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+
+		for _, target := range targets {
+			// 项目外的包只在第一次出现时定义
+			if !record[target] {
+				if n.isStdlib(target) {
+					content.WriteString(fmt.Sprintf("\"%s\" [shape=\"ellipse\", style=\"dashed\"];\n", target))
+				} else {
+					content.WriteString(fmt.Sprintf("\"%s\" [shape=\"ellipse\", color=\"gray\"];\n", target))
+				}
+				record[target] = true
+			}
+
+			count := imports[packagePath][target]
+			attributes := fmt.Sprintf("label=\"%d\", penwidth=\"%.1f\"", count, 1+math.Log2(float64(count)))
+			if cycleOf[packagePath] > 0 && cycleOf[packagePath] == cycleOf[target] {
+				attributes += ", color=\"red\""
+			}
+			content.WriteString(fmt.Sprintf("\"%s\" -> \"%s\" [%s];\n", packagePath, target, attributes))
+		}
+	}
+	content.WriteString("}")
+
+	n.writeGraph("packages", content)
+}
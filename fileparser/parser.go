@@ -1,6 +1,10 @@
 package fileparser
 
 import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
 	"strings"
 )
 
@@ -8,131 +12,139 @@ type Parser interface {
 	Merge()
 	DrawCalleeFunction(baseName string, count int)
 	DrawCallerFunction(baseName string, count int)
+	DrawCallPath(source string, target string, k int)
 	DrawStruct(baseName string, count int)
+	DrawStructByTag(baseName string, tag string, count int)
+	DrawInterface(baseName string, count int)
+	DrawType(baseName string, count int)
+	DrawPackages(thirdParty bool, stdlib bool)
+	DrawPackageCalls()
+	DrawGlobals(baseName string, constants bool)
 	GetStructCodeSnippet(baseName string) map[string]string
+	GetStructTags(baseName string) map[string]string
+	GetStructsWithTag(tag string) []string
 	GetFunctionCalleeCodeSnippet(baseName string) map[string]string
 	GetFunctionCallerCodeSnippet(baseName string) map[string]string
+	GetCallPaths(source string, target string, k int) [][]string
+	GetImplementations(baseName string) map[string]bool
+	GetImplementedInterfaces(baseName string) map[string]bool
+	GetFunctionTests(baseName string) []string
+	GetUntestedFunctions(exportedOnly bool) map[string][]string
+	GetDeadFunctions() map[string][]string
+	GetCallCycles() [][]string
+	GetStructCycles() [][]string
+	GetPackageCallCycles() [][]string
+	ExportDeadCode(w io.Writer, format ReportFormat) error
 	Inspect(file string) error
+	Skip(file string, reason string)
+	GetSkippedFiles() map[string]string
+	GetParseErrors() ParseErrors
+	CheckParseErrors() error
 	Relation() map[string]map[string][]string
+	ExportJSON(w io.Writer) error
+	Graph() *Graph
 }
 
-func NewParser(projectPath string) Parser {
-
+func NewParser(projectPath string, options ...Option) Parser {
+	moduleDir, modulePath := findModule(projectPath)
 	return &NodeManager{
 		projectPath:         projectPath,
+		config:              NewConfig(options...),
 		packages:            make(map[string][]*FileNode, 0),
-		structTypes:         make(map[string]map[string]*StructNode, 0),
-		functionNames:       make(map[string]map[string]bool, 0),
-		interfaceNames:      make(map[string]map[string]*InterfaceNode, 0),
 		allFunctions:        make(map[string][]*FunctionNode, 0),
 		allStructs:          make(map[string]*StructNode, 0),
 		allInterfaces:       make(map[string]*InterfaceNode, 0),
+		allTypes:            make(map[string]*TypeNode, 0),
+		allGlobals:          make(map[string]*GlobalNode, 0),
 		knownModuleFunction: make(map[string]bool, 0),
+		packageNames:        make(map[string]map[string]bool, 0),
+		fset:                token.NewFileSet(),
+		moduleDir:           moduleDir,
+		modulePath:          modulePath,
+		info: &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue, 0),
+			Defs:       make(map[*ast.Ident]types.Object, 0),
+			Uses:       make(map[*ast.Ident]types.Object, 0),
+			Implicits:  make(map[ast.Node]types.Object, 0),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection, 0),
+			Instances:  make(map[*ast.Ident]types.Instance, 0),
+		},
+		typesPackages:    make(map[string]*types.Package, 0),
+		stdlibPackages:   make(map[string]bool, 0),
+		functionObjects:  make(map[*types.Func]*FunctionNode, 0),
+		structObjects:    make(map[*types.TypeName]*StructNode, 0),
+		interfaceObjects: make(map[*types.TypeName]*InterfaceNode, 0),
+		typeObjects:      make(map[*types.TypeName]*TypeNode, 0),
+		globalObjects:    make(map[types.Object]*GlobalNode, 0),
+		implementMethods: make(map[*FunctionNode][]*FunctionNode, 0),
+		interfaceMethods: make(map[*FunctionNode][]*FunctionNode, 0),
+		skippedFiles:     make(map[string]string, 0),
+		parseErrors:      make(ParseErrors, 0),
+		callCycleOf:      make(map[string]int, 0),
+		typeCycleOf:      make(map[string]int, 0),
 	}
 }
 
-func getStructType(fieldType string, structType string) string {
-	// 除去chan的影响
-	if strings.HasPrefix(fieldType, "chan") {
-		fieldType = fieldType[len("chan"):]
-	}
-	fieldType = strings.ReplaceAll(fieldType, " ", "")
+// 类型参数，比如 K, V comparable
+type typeParam struct {
+	names      []string
+	constraint string
+}
 
-	// 基础类型
-	if fieldType == "string" || fieldType == "int" || fieldType == "uint" || fieldType == "uint8" || fieldType == "uint16" || fieldType == "uint32" || fieldType == "uint64" ||
-		fieldType == "int8" || fieldType == "int16" || fieldType == "int32" || fieldType == "int64" || fieldType == "float32" ||
-		fieldType == "float64" || fieldType == "complex32" || fieldType == "complex64" || fieldType == "byte" || fieldType == "rune" || fieldType == "uintptr" {
-		return ""
-	}
-	// 精准匹配
-	if fieldType == structType || fieldType == "*"+structType {
-		return structType
+func getTypeParams(list *ast.FieldList) []*typeParam {
+	typeParams := make([]*typeParam, 0)
+	if list == nil {
+		return typeParams
 	}
-	// 取最长匹配（避免像map这种干扰)
-	if strings.Contains(fieldType, "]"+structType) || strings.Contains(fieldType, "]*"+structType) {
-		return structType
+	for _, field := range list.List {
+		param := &typeParam{
+			names:      make([]string, 0),
+			constraint: types.ExprString(field.Type),
+		}
+		for _, name := range field.Names {
+			param.names = append(param.names, name.Name)
+		}
+		typeParams = append(typeParams, param)
 	}
-	return ""
+	return typeParams
 }
 
-func typeCompare(structTypes map[string]map[string]*StructNode, interfaceNames map[string]map[string]*InterfaceNode, fieldType string) (*StructNode, *InterfaceNode) {
-
-	// 先去掉包前缀
-	var packageName string
-	if strings.Contains(fieldType, ".") {
-		var subFieldType string
-		subFieldType = fieldType
-		for {
-			index := strings.Index(subFieldType, ".")
-			if index != -1 {
-				found := false
-				for i := index; i >= 0; i-- {
-					if subFieldType[i] == ' ' || subFieldType[i] == '*' || subFieldType[i] == ']' {
-						found = true
-						packageName = subFieldType[i+1 : index]
-						subFieldType = subFieldType[index+1:]
-						break
-					}
-				}
-				if !found {
-					packageName = subFieldType[:index]
-					subFieldType = subFieldType[index+1:]
-				}
-			} else {
-				break
-			}
-		}
+func formatTypeParams(typeParams []*typeParam) string {
+	if len(typeParams) == 0 {
+		return ""
 	}
-
-	var finalStructTypeStr string
-
-	var finalStructType *StructNode
-
-	var finalInterfaceType *InterfaceNode
-
-	// 如果有包名，就先以包为标准
-	if len(packageName) != 0 {
-		if _, ok := structTypes[packageName]; ok == true {
-			// 找到最合适的匹配点
-			for structType, value := range structTypes[packageName] {
-				tmp := getStructType(fieldType, packageName+"."+structType)
-				if len(tmp) > len(finalStructTypeStr) {
-					finalStructTypeStr = tmp
-					finalStructType = value
-				}
-			}
-		}
-
-		if _, ok := interfaceNames[packageName]; ok == true {
-			for structType, value := range interfaceNames[packageName] {
-				tmp := getStructType(fieldType, packageName+"."+structType)
-				if len(tmp) > len(finalStructTypeStr) {
-					finalStructTypeStr = tmp
-					finalInterfaceType = value
-				}
-			}
-		}
-	} else {
-		for _, types := range structTypes {
-			for structType, value := range types {
-				tmp := getStructType(fieldType, structType)
-				if len(tmp) > len(finalStructTypeStr) {
-					finalStructTypeStr = tmp
-					finalStructType = value
-				}
-			}
+	elems := make([]string, 0)
+	for _, param := range typeParams {
+		if param.constraint == "" {
+			elems = append(elems, strings.Join(param.names, ", "))
+		} else {
+			elems = append(elems, strings.Join(param.names, ", ")+" "+param.constraint)
 		}
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
 
-		for _, types := range interfaceNames {
-			for structType, value := range types {
-				tmp := getStructType(fieldType, structType)
-				if len(tmp) > len(finalStructTypeStr) {
-					finalStructTypeStr = tmp
-					finalInterfaceType = value
-				}
+// 解析接收者的类型名，泛型接收者同时返回类型参数，比如 *Repo[K, V] 返回 Repo 和 [K V]
+func getReceiverType(expr ast.Expr) (string, []*typeParam) {
+	typeParams := make([]*typeParam, 0)
+	for {
+		switch x := expr.(type) {
+		case *ast.StarExpr:
+			expr = x.X
+		case *ast.ParenExpr:
+			expr = x.X
+		case *ast.IndexExpr:
+			typeParams = append(typeParams, &typeParam{names: []string{types.ExprString(x.Index)}})
+			expr = x.X
+		case *ast.IndexListExpr:
+			for _, index := range x.Indices {
+				typeParams = append(typeParams, &typeParam{names: []string{types.ExprString(index)}})
 			}
+			expr = x.X
+		case *ast.Ident:
+			return x.Name, typeParams
+		default:
+			return types.ExprString(expr), typeParams
 		}
 	}
-
-	return finalStructType, finalInterfaceType
 }
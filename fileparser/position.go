@@ -0,0 +1,119 @@
+package fileparser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 默认的源码链接，可以通过WithSourceURL换成编辑器的协议，比如 vscode://file/{file}:{line}:{column}
+const defaultSourceURL = "file://{file}"
+
+func WithSourceURL(template string) Option {
+	return func(config *Config) {
+		config.SourceURL = template
+	}
+}
+
+// 位置对应的源码链接
+func (c *Config) getSourceURL(position token.Position) string {
+	file, err := filepath.Abs(position.Filename)
+	if err != nil {
+		file = position.Filename
+	}
+	replacer := strings.NewReplacer(
+		"{file}", filepath.ToSlash(file),
+		"{line}", strconv.Itoa(position.Line),
+		"{column}", strconv.Itoa(position.Column),
+	)
+	return replacer.Replace(c.SourceURL)
+}
+
+// 图中节点以及边的tooltip和URL属性，多个位置时URL指向第一个
+func (c *Config) getSourceAttributes(positions ...token.Position) string {
+	tooltips := make([]string, 0)
+	for _, position := range positions {
+		if position.IsValid() {
+			tooltips = append(tooltips, position.String())
+		}
+	}
+	if len(tooltips) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("tooltip=\"%s\", URL=\"%s\"", strings.Join(tooltips, "\\n"), c.getSourceURL(positions[0]))
+}
+
+// 代码片段的第一行标明声明的位置，随后是完整的注释
+func formatSnippet(position token.Position, doc string, content string) string {
+	content = formatDocComment(doc) + content
+	if !position.IsValid() {
+		return content
+	}
+	return "// " + position.String() + "\n" + content
+}
+
+// 节点在文件中的偏移，extra为结束位置之后多截取的字符数，
+// 语法错误恢复出的节点位置可能无效或者超出文件，偏移限制在文件范围内
+func getNodeOffsets(content []byte, base token.Pos, node ast.Node, extra int) (int, int, bool) {
+	if !node.Pos().IsValid() || !node.End().IsValid() {
+		return 0, 0, false
+	}
+	start := clampOffset(int(node.Pos()-base), len(content))
+	end := clampOffset(int(node.End()-base)+extra, len(content))
+	if start > end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// 节点对应的源码，位置无效时返回空
+func getNodeSource(content []byte, base token.Pos, node ast.Node, extra int) string {
+	start, end, ok := getNodeOffsets(content, base, node, extra)
+	if !ok {
+		return ""
+	}
+	return string(content[start:end])
+}
+
+func clampOffset(offset int, size int) int {
+	if offset < 0 {
+		return 0
+	}
+	if offset > size {
+		return size
+	}
+	return offset
+}
+
+// 类型声明所在的GenDecl
+func getTypeDecls(f *ast.File) map[*ast.TypeSpec]*ast.GenDecl {
+	decls := make(map[*ast.TypeSpec]*ast.GenDecl, 0)
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+				decls[typeSpec] = genDecl
+			}
+		}
+	}
+	return decls
+}
+
+// 类型声明的源码，单独的声明从type关键字开始截取，分组声明中的类型补上type关键字，
+// 结构体、接口以及其它具名类型的代码片段格式一致
+func getTypeSource(content []byte, base token.Pos, decl *ast.GenDecl, spec *ast.TypeSpec) string {
+	if decl != nil && !decl.Lparen.IsValid() {
+		return getNodeSource(content, base, decl, 1)
+	}
+	source := getNodeSource(content, base, spec, 1)
+	if source == "" {
+		return ""
+	}
+	return token.TYPE.String() + " " + source
+}
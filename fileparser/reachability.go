@@ -0,0 +1,110 @@
+package fileparser
+
+import (
+	"go/types"
+)
+
+// 从给定的函数出发，沿着调用关系找出全部可达的函数，调用接口方法时认为接口的全部实现都可达
+func (n *NodeManager) getReachable(roots []*FunctionNode) map[*FunctionNode]bool {
+	reachable := make(map[*FunctionNode]bool, 0)
+	queue := append([]*FunctionNode{}, roots...)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if reachable[node] {
+			continue
+		}
+		reachable[node] = true
+
+		for _, callee := range node.callee {
+			queue = append(queue, callee)
+		}
+		queue = append(queue, n.getImplementMethods(node)...)
+	}
+	return reachable
+}
+
+// 反方向查找，能够到达给定函数的全部函数
+func (n *NodeManager) getReachableBy(target *FunctionNode) map[*FunctionNode]bool {
+	reachable := make(map[*FunctionNode]bool, 0)
+	queue := []*FunctionNode{target}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if reachable[node] {
+			continue
+		}
+		reachable[node] = true
+
+		for _, caller := range node.caller {
+			queue = append(queue, caller)
+		}
+		queue = append(queue, n.getInterfaceMethods(node)...)
+	}
+	return reachable
+}
+
+// 建立接口方法和实现方法之间的对应关系，包括嵌入字段提升的方法
+func (n *NodeManager) mergeImplementMethods() {
+	for _, nodes := range n.allFunctions {
+		for _, method := range nodes {
+			if !method.isInterfaceMethod() || method.object == nil {
+				continue
+			}
+			interfaceNode := n.allInterfaces[method.fileNode.getIdentity(method.receiver)]
+			if interfaceNode == nil {
+				continue
+			}
+			implementObjects := make([]*types.TypeName, 0)
+			for _, structNode := range interfaceNode.implementStruct {
+				implementObjects = append(implementObjects, structNode.object)
+			}
+			for _, typeNode := range interfaceNode.implementTypes {
+				implementObjects = append(implementObjects, typeNode.object)
+			}
+			for _, object := range implementObjects {
+				// 嵌入接口的结构体，方法就是接口方法本身
+				if node := n.lookupMethod(object, method); node != nil && node != method && !containsFunction(n.implementMethods[method], node) {
+					n.implementMethods[method] = append(n.implementMethods[method], node)
+					n.interfaceMethods[node] = append(n.interfaceMethods[node], method)
+				}
+			}
+		}
+	}
+}
+
+// 嵌入字段提升的方法可能同时属于多个实现
+func containsFunction(nodes []*FunctionNode, node *FunctionNode) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// 接口方法在各个实现中对应的方法
+func (n *NodeManager) getImplementMethods(method *FunctionNode) []*FunctionNode {
+	return n.implementMethods[method]
+}
+
+// 方法实现的接口方法
+func (n *NodeManager) getInterfaceMethods(method *FunctionNode) []*FunctionNode {
+	return n.interfaceMethods[method]
+}
+
+// 在类型的方法集中查找同名方法，包括嵌入字段提升的方法
+func (n *NodeManager) lookupMethod(object *types.TypeName, method *FunctionNode) *FunctionNode {
+	if object == nil || method.object == nil {
+		return nil
+	}
+	selection := types.NewMethodSet(types.NewPointer(object.Type())).Lookup(method.object.Pkg(), method.name)
+	if selection == nil {
+		return nil
+	}
+	function, ok := selection.Obj().(*types.Func)
+	if !ok {
+		return nil
+	}
+	return n.functionObjects[function.Origin()]
+}
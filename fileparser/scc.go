@@ -0,0 +1,68 @@
+package fileparser
+
+import "sort"
+
+// Tarjan算法求强连通分量，只返回构成环的分量，也就是多个节点或者带自环的单个节点
+func getCycles(nodes []string, edges map[string][]string) [][]string {
+	index := make(map[string]int, 0)
+	lowLink := make(map[string]int, 0)
+	onStack := make(map[string]bool, 0)
+	stack := make([]string, 0)
+	cycles := make([][]string, 0)
+
+	var connect func(node string)
+	connect = func(node string) {
+		index[node] = len(index)
+		lowLink[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range edges[node] {
+			if _, ok := index[next]; !ok {
+				connect(next)
+				if lowLink[next] < lowLink[node] {
+					lowLink[node] = lowLink[next]
+				}
+			} else if onStack[next] && index[next] < lowLink[node] {
+				lowLink[node] = index[next]
+			}
+		}
+
+		if lowLink[node] != index[node] {
+			return
+		}
+		component := make([]string, 0)
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == node {
+				break
+			}
+		}
+		if len(component) > 1 || hasSelfLoop(node, edges) {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, node := range nodes {
+		if _, ok := index[node]; !ok {
+			connect(node)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
+
+func hasSelfLoop(node string, edges map[string][]string) bool {
+	for _, next := range edges[node] {
+		if next == node {
+			return true
+		}
+	}
+	return false
+}
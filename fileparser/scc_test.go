@@ -0,0 +1,175 @@
+package fileparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []string
+		edges map[string][]string
+		want  [][]string
+	}{
+		{
+			name:  "没有边的单个节点不是环",
+			nodes: []string{"a"},
+			want:  [][]string{},
+		},
+		{
+			name:  "自环的单个节点是环",
+			nodes: []string{"a", "b"},
+			edges: map[string][]string{"a": {"a", "b"}},
+			want:  [][]string{{"a"}},
+		},
+		{
+			name:  "两个节点相互指向",
+			nodes: []string{"a", "b"},
+			edges: map[string][]string{"a": {"b"}, "b": {"a"}},
+			want:  [][]string{{"a", "b"}},
+		},
+		{
+			name:  "没有环",
+			nodes: []string{"a", "b", "c"},
+			edges: map[string][]string{"a": {"b", "c"}, "b": {"c"}},
+			want:  [][]string{},
+		},
+		{
+			name:  "多个分量按第一个节点排序，分量内的节点排序",
+			nodes: []string{"x", "a", "d"},
+			edges: map[string][]string{
+				"x": {"z", "a"},
+				"z": {"y", "d"},
+				"y": {"x"},
+				"a": {"c"},
+				"c": {"b"},
+				"b": {"a"},
+				"d": {"d"},
+			},
+			want: [][]string{{"a", "b", "c"}, {"d"}, {"x", "y", "z"}},
+		},
+		{
+			name:  "通过桥连接的两个环是不同的分量",
+			nodes: []string{"a", "b", "c", "d"},
+			edges: map[string][]string{"a": {"b"}, "b": {"a", "c"}, "c": {"d"}, "d": {"c"}},
+			want:  [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			name:  "边指向不在nodes中的节点",
+			nodes: []string{"a"},
+			edges: map[string][]string{"a": {"b"}, "b": {"a"}},
+			want:  [][]string{{"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getCycles(tt.nodes, tt.edges); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getCycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCallCycles(t *testing.T) {
+	nodeManager := parseSource(t, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"main.go": `package main
+
+import "example.com/app/worker"
+
+type Node struct{ children Nodes }
+
+type Nodes []*Node
+
+type List struct{ next *List }
+
+type leaf struct{ value int }
+
+type task struct{}
+
+func (task) Run() { worker.Done() }
+
+func fact(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return n * fact(n-1)
+}
+
+func even(n int) bool {
+	if n == 0 {
+		return true
+	}
+	return odd(n - 1)
+}
+
+func odd(n int) bool {
+	if n == 0 {
+		return false
+	}
+	return even(n - 1)
+}
+
+func main() {
+	fact(3)
+	even(3)
+	worker.Start(task{})
+}
+`,
+		"worker/worker.go": `package worker
+
+type Runner interface{ Run() }
+
+func Start(r Runner) { r.Run() }
+
+func Done() {}
+`,
+	})
+
+	if got, want := nodeManager.GetCallCycles(), [][]string{{`"main//even"`, `"main//odd"`}, {`"main//fact"`}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetCallCycles() = %v, want %v", got, want)
+	}
+	// 经过指针以及切片的引用不构成环
+	if got, want := nodeManager.GetStructCycles(), [][]string{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetStructCycles() = %v, want %v", got, want)
+	}
+	if got, want := nodeManager.GetPackageCallCycles(), [][]string{{"main", "worker"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPackageCallCycles() = %v, want %v", got, want)
+	}
+}
+
+func TestGetStructCycles(t *testing.T) {
+	// 按值相互包含的类型无法通过类型检查，宽松模式下仍然能够解析出来
+	nodeManager := parseSource(t, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"main.go": `package main
+
+type A struct {
+	b    B
+	next *A
+}
+
+type B struct{ items [2]A }
+
+type C D
+
+type D struct{ c C }
+
+type Tree struct {
+	left, right *Tree
+	children    []Tree
+	index       map[string]Tree
+	visit       func(Tree) Tree
+	events      chan Tree
+}
+
+func main() {}
+`,
+	})
+
+	want := [][]string{{`"main/A"`, `"main/B"`}, {`"main/C"`, `"main/D"`}}
+	if got := nodeManager.GetStructCycles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetStructCycles() = %v, want %v", got, want)
+	}
+}
@@ -3,81 +3,147 @@ package fileparser
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
 	"strings"
 )
 
 type StructNode struct {
-	fileNode               *FileNode
-	name                   string
-	content                string
-	fields                 map[string]string
+	fileNode *FileNode
+	name     string
+	content  string
+	fields   map[string]string
+	// 字段按声明顺序排列的名称
+	fieldNames             []string
 	complexStructFields    map[string]*StructNode
 	complexInterfaceFields map[string]*InterfaceNode
+	implementInterfaces    map[string]*InterfaceNode
+	// 匿名嵌入的成员，key为成员的类型
+	embeddedFields     map[string]bool
+	embeddedStructs    map[string]*StructNode
+	embeddedInterfaces map[string]*InterfaceNode
+	// 字段的tag以及类型的ast，key为字段名
+	tags       map[string]reflect.StructTag
+	fieldTypes map[string]ast.Expr
+	// 字段引用的结构体以及接口，带有经过的容器
+	fieldRelations []*fieldRelation
+	typeParams     []*typeParam
+	typeSpec       *ast.TypeSpec
+	object         *types.TypeName
+	position       token.Position
+	doc            string
 }
 
 func NewStructNode(fileNode *FileNode, name string, content string, fields map[string]string) *StructNode {
+	// 不知道声明顺序时按名称排序，保证每次输出一致
+	fieldNames := make([]string, 0, len(fields))
+	for name := range fields {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+
 	return &StructNode{
 		fileNode:               fileNode,
 		name:                   name,
 		content:                content,
 		fields:                 fields,
+		fieldNames:             fieldNames,
 		complexStructFields:    make(map[string]*StructNode, 0),
 		complexInterfaceFields: make(map[string]*InterfaceNode, 0),
+		implementInterfaces:    make(map[string]*InterfaceNode, 0),
+		embeddedFields:         make(map[string]bool, 0),
+		fieldTypes:             make(map[string]ast.Expr, 0),
+		fieldRelations:         make([]*fieldRelation, 0),
+		tags:                   make(map[string]reflect.StructTag, 0),
+		embeddedStructs:        make(map[string]*StructNode, 0),
+		embeddedInterfaces:     make(map[string]*InterfaceNode, 0),
 	}
 }
 
-func (s *StructNode) Merge(structTypes map[string]map[string]*StructNode, interfaceNames map[string]map[string]*InterfaceNode) {
-
-	// 对于每个field，查看项目中的全部struct，这里只是做简单include判断，如果包括就认为是对应的类型
-	for name, t := range s.fields {
-		if strings.Contains(t, "map[") {
-			index1 := strings.Index(t, "map[")
-			index2 := strings.Index(t[index1:], "]") + index1
-			t2 := t[index1+4 : index2]
-			t3 := t[index2+1:]
-			finalStructType, finalInterfaceType := typeCompare(structTypes, interfaceNames, t2)
+func (s *StructNode) Merge() {
+	nodeManager := s.fileNode.nodeManager
 
-			if finalStructType != nil {
-				s.complexStructFields[name+":"+t2] = finalStructType
+	// 根据字段类型的ast找到引用的结构体以及接口
+	for _, name := range s.fieldNames {
+		expr := s.fieldTypes[name]
+		// 嵌入的类型，方法会被提升到当前结构体
+		if s.embeddedFields[name] {
+			object := nodeManager.getReferencedType(expr)
+			if node, ok := nodeManager.structObjects[object]; ok {
+				s.embeddedStructs[node.getIdentity()] = node
 			}
-
-			if finalInterfaceType != nil {
-				s.complexInterfaceFields[name+":"+t2] = finalInterfaceType
-			}
-
-			finalStructType2, finalInterfaceType2 := typeCompare(structTypes, interfaceNames, t3)
-
-			if finalStructType2 != nil {
-				s.complexStructFields[name+":"+t3] = finalStructType2
+			if node, ok := nodeManager.interfaceObjects[object]; ok {
+				s.embeddedInterfaces[node.getIdentity()] = node
 			}
+		}
+		s.mergeField(name, expr, nil)
+	}
 
-			if finalInterfaceType2 != nil {
-				s.complexInterfaceFields[name+":"+t3] = finalInterfaceType2
+	// 类型参数的约束
+	if s.typeSpec != nil && s.typeSpec.TypeParams != nil {
+		for _, field := range s.typeSpec.TypeParams.List {
+			names := make([]string, 0)
+			for _, name := range field.Names {
+				names = append(names, name.Name)
 			}
-		} else {
-			finalStructType, finalInterfaceType := typeCompare(structTypes, interfaceNames, t)
+			s.mergeField("["+strings.Join(names, ", ")+"]", field.Type, []string{containerConstraint})
+		}
+	}
+}
 
-			if finalStructType != nil {
-				s.complexStructFields[name+":"+t] = finalStructType
-			}
+func (s *StructNode) mergeField(name string, expr ast.Expr, containers []string) {
+	nodeManager := s.fileNode.nodeManager
+	nodeManager.walkTypeExpr(expr, containers, func(object *types.TypeName, containers []string) {
+		relation := nodeManager.newFieldRelation(object, name, containers)
+		if relation == nil {
+			return
+		}
+		if relation.structNode != nil {
+			s.complexStructFields[name+":"+relation.structNode.getIdentity()] = relation.structNode
+		}
+		if relation.interfaceNode != nil {
+			s.complexInterfaceFields[name+":"+relation.interfaceNode.getIdentity()] = relation.interfaceNode
+		}
+		s.fieldRelations = append(s.fieldRelations, relation)
+	})
+}
 
-			if finalInterfaceType != nil {
-				s.complexInterfaceFields[name+":"+t] = finalInterfaceType
-			}
+// 嵌入字段本身的关系已经用embeds表示
+func (s *StructNode) isEmbeddedRelation(relation *fieldRelation) bool {
+	if !s.embeddedFields[relation.field] {
+		return false
+	}
+	for _, container := range relation.containers {
+		if container != containerPointer {
+			return false
 		}
 	}
+	_, isStruct := s.embeddedStructs[relation.getIdentity()]
+	_, isInterface := s.embeddedInterfaces[relation.getIdentity()]
+	return isStruct || isInterface
 }
 
 func (s *StructNode) GetCodeSnippet() map[string]string {
 	result := make(map[string]string, 0)
-	result[s.getIdentity()] = s.content
+	result[s.getDisplayName()] = s.getSnippet()
 	for _, node := range s.complexInterfaceFields {
-		result[node.getIdentity()] = node.content
+		result[node.getDisplayName()] = node.getSnippet()
+	}
+
+	for _, node := range s.getFieldTypes() {
+		if _, ok := result[node.getDisplayName()]; ok == false {
+			for k, v := range node.GetCodeSnippet() {
+				result[k] = v
+			}
+		}
 	}
 
 	for _, node := range s.complexStructFields {
-		if _, ok := result[node.getIdentity()]; ok == false {
-			result[node.getIdentity()] = node.content
+		if _, ok := result[node.getDisplayName()]; ok == false {
+			result[node.getDisplayName()] = node.getSnippet()
 			tmp := node.GetCodeSnippet()
 			for k, v := range tmp {
 				result[k] = v
@@ -87,63 +153,174 @@ func (s *StructNode) GetCodeSnippet() map[string]string {
 	return result
 }
 
+func (s *StructNode) getSnippet() string {
+	return formatSnippet(s.position, s.doc, s.content)
+}
+
 func (s *StructNode) getIdentity() string {
-	return "\"" + s.fileNode.packageName + "/" + s.name + "\""
+	return s.fileNode.getIdentity(s.name)
+}
+
+func (s *StructNode) getDisplayName() string {
+	return "\"" + s.fileNode.getPackageDisplayName() + "/" + s.name + "\""
 }
 
 func (s *StructNode) getStructLabel() string {
+	return s.getTagLabel("")
+}
+
+// 字段名换成tag中的名称，tag为空时使用字段名
+func (s *StructNode) getTagLabel(tag string) string {
 	buffer := bytes.NewBuffer([]byte{})
-	for name, t := range s.fields {
+	for _, name := range s.fieldNames {
+		t := s.fields[name]
+		if tag != "" {
+			tagName, ok := s.getTagName(name, tag)
+			if !ok {
+				continue
+			}
+			name = tagName
+		}
 		buffer.WriteString(fmt.Sprintf("%s: %s\\l\\n", name, t))
 	}
-	label := fmt.Sprintf("struct: %s\\l\\n----\\lpackage: %s\\l\\nfile: %s\\l----\\l%s", s.name, s.fileNode.packageName, s.fileNode.fileNodeTagName, buffer.String())
+	header := s.name + formatTypeParams(s.typeParams)
+	if tag != "" {
+		header += " (" + tag + ")"
+	}
+	label := fmt.Sprintf("struct: %s\\l\\n----\\lpackage: %s\\l\\nfile: %s\\l%s----\\l%s", header, s.fileNode.getPackageDisplayName(), s.fileNode.fileNodeTagName, s.fileNode.nodeManager.config.getDocLabel(s.doc), buffer.String())
 	return label
 }
 
 func (s *StructNode) DrawNode(content *bytes.Buffer, record map[string]bool, count int) {
+	s.DrawTagNode(content, record, count, "")
+}
 
-	content.WriteString(fmt.Sprintf("%s [label=\"%s\", shape=\"box\"];", s.getIdentity(), s.getStructLabel()))
+// 用tag中的名称绘制结构体以及它引用的结构体
+func (s *StructNode) DrawTagNode(content *bytes.Buffer, record map[string]bool, count int, tag string) {
+	attributes := "shape=\"box\""
+	// 相互包含的结构体标红
+	if s.fileNode.nodeManager.typeCycleOf[s.getIdentity()] > 0 {
+		attributes += ", color=\"red\""
+	}
+	content.WriteString(fmt.Sprintf("%s [label=\"%s\", %s, %s];", s.getIdentity(), s.getTagLabel(tag), attributes, s.fileNode.nodeManager.config.getSourceAttributes(s.position)))
 	content.WriteString("\n")
 
 	record[s.getIdentity()] = true
 	count--
 	if count > 0 {
-		for _, node := range s.complexStructFields {
-			node.DrawNode(content, record, count)
+		for _, node := range s.getFieldStructs() {
+			node.DrawTagNode(content, record, count, tag)
 		}
 	}
 
 	if count > 0 {
-		for _, node := range s.complexInterfaceFields {
+		for _, node := range s.getFieldInterfaces() {
 			node.DrawNode(content, record)
 		}
+		for _, node := range s.getFieldTypes() {
+			node.DrawNode(content, record, count)
+		}
 	}
 }
 
 func (s *StructNode) DrawRelation(content *bytes.Buffer, record map[string]bool, count int) {
 
 	tempRecord := make(map[string]bool, 0)
-	for _, node := range s.complexStructFields {
-		if tempRecord[node.getIdentity()] == false {
-			content.WriteString(fmt.Sprintf("%s -> %s", s.getIdentity(), node.getIdentity()))
+	// 嵌入关系单独用粗线表示
+	for _, relation := range s.fieldRelations {
+		if s.isEmbeddedRelation(relation) && tempRecord[relation.getIdentity()] == false {
+			color := ""
+			if s.isCycleRelation(relation) {
+				color = ", color=\"red\""
+			}
+			content.WriteString(fmt.Sprintf("%s -> %s [style=\"bold\", arrowhead=\"diamond\", label=\"embeds\"%s]", s.getIdentity(), relation.getIdentity(), color))
 			content.WriteString("\n")
-			tempRecord[node.getIdentity()] = true
+			tempRecord[relation.getIdentity()] = true
 		}
 	}
-	for _, node := range s.complexInterfaceFields {
-		if tempRecord[s.getIdentity()+node.getIdentity()] == false {
-			content.WriteString(fmt.Sprintf("%s -> %s", s.getIdentity(), node.getIdentity()))
+	// 字段引用的类型，边上标注经过的容器
+	for _, relation := range s.fieldRelations {
+		if s.isEmbeddedRelation(relation) {
+			continue
+		}
+		key := relation.getIdentity() + relation.getLabel()
+		if tempRecord[key] == false {
+			content.WriteString(fmt.Sprintf("%s -> %s%s", s.getIdentity(), relation.getIdentity(), s.getFieldAttributes(relation)))
 			content.WriteString("\n")
-			tempRecord[s.getIdentity()+node.getIdentity()] = true
+			tempRecord[key] = true
 		}
 	}
 	record[s.getIdentity()] = true
 	count--
 	if count > 0 {
-		for _, node := range s.complexStructFields {
+		for _, node := range s.getFieldStructs() {
 			if record[node.getIdentity()] == false {
 				node.DrawRelation(content, record, count)
 			}
 		}
+		for _, node := range s.getFieldInterfaces() {
+			node.DrawEmbeddedRelation(content, record)
+		}
+		for _, node := range s.getFieldTypes() {
+			if record[node.getIdentity()] == false {
+				node.DrawRelation(content, record, count)
+			}
+		}
+	}
+}
+
+// 相互包含的结构体之间按值包含的字段关系标红
+func (s *StructNode) isCycleRelation(relation *fieldRelation) bool {
+	return relation.isValueContainment() && isSameCycle(s.fileNode.nodeManager.typeCycleOf, s.getIdentity(), relation.getIdentity())
+}
+
+func (s *StructNode) getFieldAttributes(relation *fieldRelation) string {
+	attributes := make([]string, 0)
+	if len(relation.containers) > 0 {
+		attributes = append(attributes, fmt.Sprintf("label=\"%s\"", relation.getLabel()))
+	}
+	if s.isCycleRelation(relation) {
+		attributes = append(attributes, "color=\"red\"")
+	}
+	if len(attributes) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(attributes, ", ") + "]"
+}
+
+// 字段引用的结构体，按字段的声明顺序去重
+func (s *StructNode) getFieldStructs() []*StructNode {
+	result := make([]*StructNode, 0)
+	tempRecord := make(map[*StructNode]bool, 0)
+	for _, relation := range s.fieldRelations {
+		if relation.structNode != nil && !tempRecord[relation.structNode] {
+			result = append(result, relation.structNode)
+			tempRecord[relation.structNode] = true
+		}
 	}
+	return result
+}
+
+func (s *StructNode) getFieldInterfaces() []*InterfaceNode {
+	result := make([]*InterfaceNode, 0)
+	tempRecord := make(map[*InterfaceNode]bool, 0)
+	for _, relation := range s.fieldRelations {
+		if relation.interfaceNode != nil && !tempRecord[relation.interfaceNode] {
+			result = append(result, relation.interfaceNode)
+			tempRecord[relation.interfaceNode] = true
+		}
+	}
+	return result
+}
+
+func (s *StructNode) getFieldTypes() []*TypeNode {
+	result := make([]*TypeNode, 0)
+	tempRecord := make(map[*TypeNode]bool, 0)
+	for _, relation := range s.fieldRelations {
+		if relation.typeNode != nil && !tempRecord[relation.typeNode] {
+			result = append(result, relation.typeNode)
+			tempRecord[relation.typeNode] = true
+		}
+	}
+	return result
 }
@@ -0,0 +1,74 @@
+package fileparser
+
+import (
+	"go/ast"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 字段上的tag，比如 `json:"id" db:"user_id"`
+func getStructTag(lit *ast.BasicLit) reflect.StructTag {
+	if lit == nil {
+		return ""
+	}
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(value)
+}
+
+// 字段在tag中的名称，没有tag时使用字段名，tag为 - 的字段不展示
+func (s *StructNode) getTagName(name string, tag string) (string, bool) {
+	value, ok := s.tags[name].Lookup(tag)
+	if !ok {
+		return name, true
+	}
+	tagName := strings.Split(value, ",")[0]
+	if tagName == "-" {
+		return "", false
+	}
+	if tagName == "" {
+		return name, true
+	}
+	return tagName, true
+}
+
+// 结构体中是否有字段带有指定的tag
+func (s *StructNode) hasTag(tag string) bool {
+	for _, structTag := range s.tags {
+		if _, ok := structTag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 结构体每个字段的完整tag
+func (n *NodeManager) GetStructTags(baseName string) map[string]string {
+	result := make(map[string]string, 0)
+	structNode := n.getMatchedStruct(baseName)
+	if structNode == nil {
+		return result
+	}
+	for name, tag := range structNode.tags {
+		if tag != "" {
+			result[name] = string(tag)
+		}
+	}
+	return result
+}
+
+// 带有指定tag的全部结构体，比如全部带gorm tag的结构体
+func (n *NodeManager) GetStructsWithTag(tag string) []string {
+	result := make([]string, 0)
+	for _, structNode := range n.allStructs {
+		if structNode.hasTag(tag) {
+			result = append(result, structNode.getDisplayName())
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,55 @@
+package fileparser
+
+import (
+	"go/ast"
+	"sort"
+)
+
+// 能够调用到该函数的测试
+func (n *NodeManager) GetFunctionTests(baseName string) []string {
+	result := make([]string, 0)
+	node := n.getMatchedFunction(baseName)
+	if node == nil {
+		return result
+	}
+
+	for caller := range n.getReachableBy(node) {
+		if caller.isTestEntry() {
+			result = append(result, caller.getDisplayName())
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// 没有任何测试能够调用到的函数，按包归类，map[package][]function
+func (n *NodeManager) GetUntestedFunctions(exportedOnly bool) map[string][]string {
+	roots := make([]*FunctionNode, 0)
+	for _, nodes := range n.allFunctions {
+		for _, node := range nodes {
+			if node.isTestEntry() {
+				roots = append(roots, node)
+			}
+		}
+	}
+	reachable := n.getReachable(roots)
+
+	result := make(map[string][]string, 0)
+	for _, nodes := range n.allFunctions {
+		for _, node := range nodes {
+			if reachable[node] || node.fileNode.isTest || node.literal != nil || node.isInterfaceMethod() {
+				continue
+			}
+			if exportedOnly && (!ast.IsExported(node.name) || (node.receiver != "" && !ast.IsExported(node.receiver))) {
+				continue
+			}
+			packageName := node.fileNode.getPackageDisplayName()
+			result[packageName] = append(result[packageName], node.getDisplayName())
+		}
+	}
+
+	for _, functions := range result {
+		sort.Strings(functions)
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package fileparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctionTests(t *testing.T) {
+	nodeManager := parseSource(t, map[string]string{
+		"go.mod": "module example.com/lib\n",
+		"lib/lib.go": `package lib
+
+func Parse() { decode() }
+
+func decode() {}
+
+func Format() {}
+
+func Validate() {}
+`,
+		"lib/lib_test.go": `package lib
+
+import "testing"
+
+func TestParse(t *testing.T) { Parse() }
+
+func BenchmarkParse(b *testing.B) { Parse() }
+
+func ExampleParse() { decode() }
+
+func Testify(t *testing.T) { Format() }
+
+func TestValidate(v int) { Validate() }
+`,
+	}, WithTests())
+
+	if got, want := nodeManager.GetFunctionTests("lib//decode"), []string{`"lib//BenchmarkParse"`, `"lib//ExampleParse"`, `"lib//TestParse"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetFunctionTests(decode) = %v, want %v", got, want)
+	}
+	if got := nodeManager.GetFunctionTests("lib//Format"); len(got) != 0 {
+		t.Errorf("GetFunctionTests(Format) = %v, want empty", got)
+	}
+	if got, want := nodeManager.GetUntestedFunctions(false), map[string][]string{"lib": {`"lib//Format"`, `"lib//Validate"`}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetUntestedFunctions() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,332 @@
+package fileparser
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 项目内的包直接用已经解析好的ast做类型检查，项目外的包交给源码importer
+type packageImporter struct {
+	nodeManager *NodeManager
+	fallback    types.ImporterFrom
+}
+
+func (p *packageImporter) Import(path string) (*types.Package, error) {
+	return p.ImportFrom(path, "", 0)
+}
+
+func (p *packageImporter) ImportFrom(path string, dir string, mode types.ImportMode) (*types.Package, error) {
+	if _, ok := p.nodeManager.packages[path]; ok {
+		return p.nodeManager.checkPackage(path)
+	}
+	return p.fallback.ImportFrom(path, dir, mode)
+}
+
+// 从源码导入项目外的包，使用配置的构建环境选择文件，依赖中按平台区分的文件和项目中的文件保持一致
+type sourceImporter struct {
+	context  build.Context
+	fset     *token.FileSet
+	packages map[string]*types.Package
+}
+
+func newSourceImporter(context build.Context, fset *token.FileSet) *sourceImporter {
+	// 不调用cgo，使用依赖中纯go的实现
+	context.CgoEnabled = false
+	return &sourceImporter{
+		context:  context,
+		fset:     fset,
+		packages: make(map[string]*types.Package, 0),
+	}
+}
+
+func (s *sourceImporter) Import(path string) (*types.Package, error) {
+	return s.ImportFrom(path, "", 0)
+}
+
+func (s *sourceImporter) ImportFrom(path string, dir string, mode types.ImportMode) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+	buildPackage, err := s.context.Import(path, dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	if pkg, ok := s.packages[buildPackage.ImportPath]; ok {
+		if pkg == nil {
+			return nil, fmt.Errorf("import cycle through package:%s", buildPackage.ImportPath)
+		}
+		return pkg, nil
+	}
+	s.packages[buildPackage.ImportPath] = nil
+
+	files := make([]*ast.File, 0, len(buildPackage.GoFiles))
+	for _, name := range buildPackage.GoFiles {
+		file, err := parser.ParseFile(s.fset, filepath.Join(buildPackage.Dir, name), nil, parser.SkipObjectResolution)
+		if file == nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	// 依赖只需要导出的声明，其中的错误不影响项目的类型检查
+	config := types.Config{
+		Importer:         s,
+		Sizes:            types.SizesFor("gc", s.context.GOARCH),
+		IgnoreFuncBodies: true,
+		Error: func(err error) {
+			Log.Sugar().Debugf("type check dependency:%s, error:%s", buildPackage.ImportPath, err.Error())
+		},
+	}
+	pkg, _ := config.Check(buildPackage.ImportPath, s.fset, files, nil)
+	s.packages[buildPackage.ImportPath] = pkg
+	return pkg, nil
+}
+
+// 向上查找go.mod，得到模块根目录以及模块路径
+func findModule(projectPath string) (string, string) {
+	projectPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return projectPath, filepath.Base(projectPath)
+	}
+
+	for dir := projectPath; ; dir = filepath.Dir(dir) {
+		if modulePath := readModulePath(filepath.Join(dir, "go.mod")); modulePath != "" {
+			return dir, modulePath
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	// 没有go.mod时按照GOPATH的方式推导
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		src := filepath.Join(gopath, "src")
+		if rel, err := filepath.Rel(src, projectPath); err == nil && !strings.HasPrefix(rel, "..") && rel != "." {
+			return projectPath, filepath.ToSlash(rel)
+		}
+	}
+	return projectPath, filepath.Base(projectPath)
+}
+
+func readModulePath(goMod string) string {
+	file, err := os.Open(goMod)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.Index(line, "//"); index != -1 {
+			line = line[:index]
+		}
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module") {
+			modulePath := strings.TrimSpace(line[len("module"):])
+			return strings.Trim(modulePath, "\"`")
+		}
+	}
+	return ""
+}
+
+// go.mod中依赖的模块，包括单行以及块形式的require
+func readRequiredModules(goMod string) []string {
+	modules := make([]string, 0)
+	file, err := os.Open(goMod)
+	if err != nil {
+		return modules
+	}
+	defer file.Close()
+
+	inBlock := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.Index(line, "//"); index != -1 {
+			line = line[:index]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inBlock && fields[0] == ")":
+			inBlock = false
+		case inBlock:
+			modules = append(modules, strings.Trim(fields[0], "\"`"))
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inBlock = true
+		case fields[0] == "require" && len(fields) > 1:
+			modules = append(modules, strings.Trim(fields[1], "\"`"))
+		}
+	}
+	return modules
+}
+
+// 根据文件所在目录推导包的导入路径
+func (n *NodeManager) getImportPath(dir string) string {
+	rel, err := filepath.Rel(n.moduleDir, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return n.modulePath
+	}
+	return n.modulePath + "/" + filepath.ToSlash(rel)
+}
+
+func (n *NodeManager) checkPackage(path string) (*types.Package, error) {
+	if pkg, ok := n.typesPackages[path]; ok {
+		if pkg == nil {
+			return nil, fmt.Errorf("import cycle through package:%s", path)
+		}
+		return pkg, nil
+	}
+	// 先占位，防止循环导入时无限递归
+	n.typesPackages[path] = nil
+
+	files := make([]*ast.File, 0)
+	for _, fileNode := range n.packages[path] {
+		files = append(files, fileNode.astFile)
+	}
+
+	config := types.Config{
+		Importer: n.importer,
+		Sizes:    types.SizesFor("gc", n.config.BuildContext.GOARCH),
+		Error: func(err error) {
+			Log.Sugar().Debugf("type check package:%s, error:%s", path, err.Error())
+		},
+	}
+	pkg, _ := config.Check(path, n.fset, files, n.info)
+	n.typesPackages[path] = pkg
+	return pkg, nil
+}
+
+// 对项目中的全部包做类型检查
+func (n *NodeManager) mergeTypes() {
+	n.importer = &packageImporter{
+		nodeManager: n,
+		fallback:    newSourceImporter(n.config.BuildContext, n.fset),
+	}
+	for path := range n.packages {
+		_, _ = n.checkPackage(path)
+	}
+}
+
+// 根据调用表达式找到被调用的函数，如果是通过嵌入字段提升的方法，同时返回经过的嵌入字段
+func (n *NodeManager) getCalledFunction(call *ast.CallExpr) (*FunctionNode, []string) {
+	fun := ast.Unparen(call.Fun)
+	// 泛型函数的显式实例化
+	switch x := fun.(type) {
+	case *ast.IndexExpr:
+		fun = x.X
+	case *ast.IndexListExpr:
+		fun = x.X
+	}
+
+	node := n.getReferencedFunction(fun)
+	if node == nil {
+		return nil, nil
+	}
+	if x, ok := fun.(*ast.SelectorExpr); ok {
+		if selection, ok := n.info.Selections[x]; ok {
+			return node, getEmbeddedPath(selection)
+		}
+	}
+	return node, nil
+}
+
+// 表达式引用的函数或者方法
+func (n *NodeManager) getReferencedFunction(expr ast.Expr) *FunctionNode {
+	var ident *ast.Ident
+	switch x := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		ident = x
+	case *ast.SelectorExpr:
+		ident = x.Sel
+	default:
+		return nil
+	}
+
+	function, ok := n.info.Uses[ident].(*types.Func)
+	if !ok {
+		return nil
+	}
+	return n.functionObjects[function.Origin()]
+}
+
+// 方法提升时经过的嵌入字段
+func getEmbeddedPath(selection *types.Selection) []string {
+	index := selection.Index()
+	if len(index) <= 1 {
+		return nil
+	}
+
+	embeddedPath := make([]string, 0)
+	t := selection.Recv()
+	for _, i := range index[:len(index)-1] {
+		if pointer, ok := t.Underlying().(*types.Pointer); ok {
+			t = pointer.Elem()
+		}
+		structType, ok := t.Underlying().(*types.Struct)
+		if !ok || i >= structType.NumFields() {
+			return embeddedPath
+		}
+		field := structType.Field(i)
+		embeddedPath = append(embeddedPath, field.Name())
+		t = field.Type()
+	}
+	return embeddedPath
+}
+
+func (n *NodeManager) getTypeName(spec *ast.TypeSpec) *types.TypeName {
+	if spec == nil {
+		return nil
+	}
+	object, _ := n.info.Defs[spec.Name].(*types.TypeName)
+	return object
+}
+
+// 找到类型表达式引用的具名类型，泛型实例化的类型取其本身
+func (n *NodeManager) getReferencedType(expr ast.Expr) *types.TypeName {
+	for {
+		switch x := expr.(type) {
+		case *ast.ParenExpr:
+			expr = x.X
+		case *ast.StarExpr:
+			expr = x.X
+		case *ast.IndexExpr:
+			expr = x.X
+		case *ast.IndexListExpr:
+			expr = x.X
+		case *ast.SelectorExpr:
+			expr = x.Sel
+		case *ast.Ident:
+			object, _ := n.info.Uses[x].(*types.TypeName)
+			return object
+		default:
+			return nil
+		}
+	}
+}
+
+// 拆开类型约束中的并集，比如 ~int | ~string | Stringer
+func getTypeTerms(expr ast.Expr) []ast.Expr {
+	switch x := expr.(type) {
+	case *ast.BinaryExpr:
+		if x.Op == token.OR {
+			return append(getTypeTerms(x.X), getTypeTerms(x.Y)...)
+		}
+	case *ast.UnaryExpr:
+		if x.Op == token.TILDE {
+			return getTypeTerms(x.X)
+		}
+	case *ast.ParenExpr:
+		return getTypeTerms(x.X)
+	}
+	return []ast.Expr{expr}
+}
@@ -0,0 +1,211 @@
+package fileparser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// 结构体以及接口以外的具名类型，包括类型别名，比如 type IDs []UserID, type Handler func(ctx, *Req) error
+type TypeNode struct {
+	fileNode *FileNode
+	name     string
+	content  string
+	// type Old = New
+	alias bool
+	// 底层类型引用的结构体、接口以及具名类型
+	typeRelations       []*fieldRelation
+	implementInterfaces map[string]*InterfaceNode
+	typeParams          []*typeParam
+	typeSpec            *ast.TypeSpec
+	object              *types.TypeName
+	position            token.Position
+	doc                 string
+}
+
+func NewTypeNode(fileNode *FileNode, name string, content string, alias bool) *TypeNode {
+	return &TypeNode{
+		fileNode:            fileNode,
+		name:                name,
+		content:             content,
+		alias:               alias,
+		typeRelations:       make([]*fieldRelation, 0),
+		implementInterfaces: make(map[string]*InterfaceNode, 0),
+	}
+}
+
+func (t *TypeNode) Merge() {
+	nodeManager := t.fileNode.nodeManager
+	if t.typeSpec == nil {
+		return
+	}
+	nodeManager.walkTypeExpr(t.typeSpec.Type, nil, func(object *types.TypeName, containers []string) {
+		if relation := nodeManager.newFieldRelation(object, t.name, containers); relation != nil {
+			t.typeRelations = append(t.typeRelations, relation)
+		}
+	})
+
+	// 类型参数的约束
+	if t.typeSpec.TypeParams != nil {
+		for _, field := range t.typeSpec.TypeParams.List {
+			nodeManager.walkTypeExpr(field.Type, []string{containerConstraint}, func(object *types.TypeName, containers []string) {
+				if relation := nodeManager.newFieldRelation(object, t.name, containers); relation != nil {
+					t.typeRelations = append(t.typeRelations, relation)
+				}
+			})
+		}
+	}
+}
+
+func (t *TypeNode) getIdentity() string {
+	return t.fileNode.getIdentity(t.name)
+}
+
+func (t *TypeNode) getDisplayName() string {
+	return "\"" + t.fileNode.getPackageDisplayName() + "/" + t.name + "\""
+}
+
+func (t *TypeNode) getSnippet() string {
+	return formatSnippet(t.position, t.doc, t.content)
+}
+
+// 声明在类型上的方法，按声明的顺序，别名没有自己的方法
+func (t *TypeNode) getMethods() []*FunctionNode {
+	result := make([]*FunctionNode, 0)
+	if t.alias || t.object == nil {
+		return result
+	}
+	named, ok := t.object.Type().(*types.Named)
+	if !ok {
+		return result
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		if node, ok := t.fileNode.nodeManager.functionObjects[named.Method(i)]; ok {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+func (t *TypeNode) GetCodeSnippet() map[string]string {
+	return t.getCodeSnippet(make(map[string]string, 0))
+}
+
+// 递归的类型比如 type Tree map[string]Tree，已经加入的类型不再展开
+func (t *TypeNode) getCodeSnippet(result map[string]string) map[string]string {
+	result[t.getDisplayName()] = t.getSnippet()
+	for _, relation := range t.typeRelations {
+		switch {
+		case relation.structNode != nil:
+			result[relation.structNode.getDisplayName()] = relation.structNode.getSnippet()
+		case relation.interfaceNode != nil:
+			result[relation.interfaceNode.getDisplayName()] = relation.interfaceNode.getSnippet()
+		case relation.typeNode != nil:
+			if _, ok := result[relation.typeNode.getDisplayName()]; !ok {
+				relation.typeNode.getCodeSnippet(result)
+			}
+		}
+	}
+	return result
+}
+
+func (t *TypeNode) getLabel() string {
+	kind, underlying := "type", ""
+	if t.typeSpec != nil {
+		underlying = types.ExprString(t.typeSpec.Type)
+	}
+	if t.alias {
+		kind, underlying = "alias", "= "+underlying
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	for _, method := range t.getMethods() {
+		signature := "()"
+		if method.decl != nil {
+			signature = strings.TrimPrefix(types.ExprString(method.decl.Type), "func")
+		}
+		buffer.WriteString(fmt.Sprintf("%s%s\\l\\n", method.name, signature))
+	}
+	label := fmt.Sprintf("%s: %s%s\\l\\n----\\lpackage: %s\\l\\nfile: %s\\l%s----\\l%s\\l\\n----\\l%s", kind, t.name, formatTypeParams(t.typeParams), t.fileNode.getPackageDisplayName(), t.fileNode.fileNodeTagName, t.fileNode.nodeManager.config.getDocLabel(t.doc), underlying, buffer.String())
+	return strings.ReplaceAll(label, "\"", "\\\"")
+}
+
+// 底层类型的边，直接引用时标注underlying或者alias，否则标注经过的容器
+func (t *TypeNode) getRelationAttributes(relation *fieldRelation) string {
+	attributes := make([]string, 0)
+	label := relation.getLabel()
+	if label == "" && t.alias {
+		label = "alias"
+	} else if label == "" {
+		label = "underlying"
+	}
+	attributes = append(attributes, fmt.Sprintf("label=\"%s\"", label))
+	if t.alias {
+		attributes = append(attributes, "style=\"dotted\"")
+	}
+	if relation.isValueContainment() && isSameCycle(t.fileNode.nodeManager.typeCycleOf, t.getIdentity(), relation.getIdentity()) {
+		attributes = append(attributes, "color=\"red\"")
+	}
+	return " [" + strings.Join(attributes, ", ") + "]"
+}
+
+// 绘制类型以及底层类型引用的节点
+func (t *TypeNode) DrawNode(content *bytes.Buffer, record map[string]bool, count int) {
+	if record[t.getIdentity()] == true {
+		return
+	}
+	attributes := "shape=\"box\", style=\"rounded\""
+	if t.fileNode.nodeManager.typeCycleOf[t.getIdentity()] > 0 {
+		attributes += ", color=\"red\""
+	}
+	content.WriteString(fmt.Sprintf("%s [label=\"%s\", %s, %s];", t.getIdentity(), t.getLabel(), attributes, t.fileNode.nodeManager.config.getSourceAttributes(t.position)))
+	content.WriteString("\n")
+	record[t.getIdentity()] = true
+
+	count--
+	if count > 0 {
+		for _, relation := range t.typeRelations {
+			if record[relation.getIdentity()] == true {
+				continue
+			}
+			switch {
+			case relation.structNode != nil:
+				relation.structNode.DrawNode(content, record, count)
+			case relation.interfaceNode != nil:
+				relation.interfaceNode.DrawNode(content, record)
+			case relation.typeNode != nil:
+				relation.typeNode.DrawNode(content, record, count)
+			}
+		}
+	}
+}
+
+func (t *TypeNode) DrawRelation(content *bytes.Buffer, record map[string]bool, count int) {
+	tempRecord := make(map[string]bool, 0)
+	for _, relation := range t.typeRelations {
+		key := relation.getIdentity() + relation.getLabel()
+		if tempRecord[key] == false {
+			content.WriteString(fmt.Sprintf("%s -> %s%s", t.getIdentity(), relation.getIdentity(), t.getRelationAttributes(relation)))
+			content.WriteString("\n")
+			tempRecord[key] = true
+		}
+	}
+	record[t.getIdentity()] = true
+
+	count--
+	if count > 0 {
+		for _, relation := range t.typeRelations {
+			switch {
+			case relation.structNode != nil && record[relation.getIdentity()] == false:
+				relation.structNode.DrawRelation(content, record, count)
+			case relation.interfaceNode != nil:
+				relation.interfaceNode.DrawEmbeddedRelation(content, record)
+			case relation.typeNode != nil && record[relation.getIdentity()] == false:
+				relation.typeNode.DrawRelation(content, record, count)
+			}
+		}
+	}
+}
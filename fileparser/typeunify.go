@@ -0,0 +1,197 @@
+package fileparser
+
+import (
+	"go/types"
+)
+
+// 类型参数到替换类型的映射，用来判断泛型类型的方法签名是否和接口方法一致
+type typeUnifier map[*types.TypeParam]types.Type
+
+// 已经绑定的类型参数替换成绑定的类型
+func (u typeUnifier) resolve(t types.Type) types.Type {
+	t = types.Unalias(t)
+	for {
+		typeParam, ok := t.(*types.TypeParam)
+		if !ok {
+			return t
+		}
+		bound, ok := u[typeParam]
+		if !ok {
+			return t
+		}
+		t = types.Unalias(bound)
+	}
+}
+
+// 按结构比较两个类型，没有绑定的类型参数绑定到另一边的类型上
+func (u typeUnifier) unify(x types.Type, y types.Type) bool {
+	x, y = u.resolve(x), u.resolve(y)
+	if x == y {
+		return true
+	}
+	if typeParam, ok := x.(*types.TypeParam); ok {
+		return u.bind(typeParam, y)
+	}
+	if typeParam, ok := y.(*types.TypeParam); ok {
+		return u.bind(typeParam, x)
+	}
+
+	switch x := x.(type) {
+	case *types.Pointer:
+		y, ok := y.(*types.Pointer)
+		return ok && u.unify(x.Elem(), y.Elem())
+	case *types.Slice:
+		y, ok := y.(*types.Slice)
+		return ok && u.unify(x.Elem(), y.Elem())
+	case *types.Array:
+		y, ok := y.(*types.Array)
+		return ok && x.Len() == y.Len() && u.unify(x.Elem(), y.Elem())
+	case *types.Map:
+		y, ok := y.(*types.Map)
+		return ok && u.unify(x.Key(), y.Key()) && u.unify(x.Elem(), y.Elem())
+	case *types.Chan:
+		y, ok := y.(*types.Chan)
+		return ok && x.Dir() == y.Dir() && u.unify(x.Elem(), y.Elem())
+	case *types.Signature:
+		y, ok := y.(*types.Signature)
+		return ok && x.Variadic() == y.Variadic() && u.unifyTuple(x.Params(), y.Params()) && u.unifyTuple(x.Results(), y.Results())
+	case *types.Named:
+		y, ok := y.(*types.Named)
+		if !ok || x.Origin() != y.Origin() || x.TypeArgs().Len() != y.TypeArgs().Len() {
+			return false
+		}
+		for i := 0; i < x.TypeArgs().Len(); i++ {
+			if !u.unify(x.TypeArgs().At(i), y.TypeArgs().At(i)) {
+				return false
+			}
+		}
+		return true
+	case *types.Struct:
+		y, ok := y.(*types.Struct)
+		if !ok || x.NumFields() != y.NumFields() {
+			return false
+		}
+		for i := 0; i < x.NumFields(); i++ {
+			if x.Field(i).Name() != y.Field(i).Name() || x.Field(i).Embedded() != y.Field(i).Embedded() || x.Tag(i) != y.Tag(i) {
+				return false
+			}
+			if !u.unify(x.Field(i).Type(), y.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	return types.Identical(x, y)
+}
+
+func (u typeUnifier) unifyTuple(x *types.Tuple, y *types.Tuple) bool {
+	if x.Len() != y.Len() {
+		return false
+	}
+	for i := 0; i < x.Len(); i++ {
+		if !u.unify(x.At(i).Type(), y.At(i).Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// 不允许绑定到包含类型参数自身的类型，比如 T = []T
+func (u typeUnifier) bind(typeParam *types.TypeParam, t types.Type) bool {
+	if u.occurs(typeParam, t) {
+		return false
+	}
+	u[typeParam] = t
+	return true
+}
+
+func (u typeUnifier) occurs(typeParam *types.TypeParam, t types.Type) bool {
+	return containsType(t, func(t types.Type) bool {
+		if x, ok := t.(*types.TypeParam); ok {
+			if x == typeParam {
+				return true
+			}
+			if bound, ok := u[x]; ok {
+				return u.occurs(typeParam, bound)
+			}
+		}
+		return false
+	})
+}
+
+// 绑定的类型是否满足类型参数的约束，约束或者绑定的类型中还有类型参数时不检查
+func (u typeUnifier) satisfies() bool {
+	for typeParam := range u {
+		t := u.resolve(typeParam)
+		if containsTypeParam(t) {
+			continue
+		}
+		constraint, ok := typeParam.Constraint().Underlying().(*types.Interface)
+		if !ok || containsTypeParam(constraint) {
+			continue
+		}
+		if !types.Satisfies(t, constraint) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsTypeParam(t types.Type) bool {
+	return containsType(t, func(t types.Type) bool {
+		_, ok := t.(*types.TypeParam)
+		return ok
+	})
+}
+
+// 类型中是否出现了满足条件的类型，具名类型只检查类型实参
+func containsType(t types.Type, match func(types.Type) bool) bool {
+	t = types.Unalias(t)
+	if match(t) {
+		return true
+	}
+	switch x := t.(type) {
+	case *types.Pointer:
+		return containsType(x.Elem(), match)
+	case *types.Slice:
+		return containsType(x.Elem(), match)
+	case *types.Array:
+		return containsType(x.Elem(), match)
+	case *types.Map:
+		return containsType(x.Key(), match) || containsType(x.Elem(), match)
+	case *types.Chan:
+		return containsType(x.Elem(), match)
+	case *types.Named:
+		for i := 0; i < x.TypeArgs().Len(); i++ {
+			if containsType(x.TypeArgs().At(i), match) {
+				return true
+			}
+		}
+	case *types.Tuple:
+		for i := 0; i < x.Len(); i++ {
+			if containsType(x.At(i).Type(), match) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return containsType(x.Params(), match) || containsType(x.Results(), match)
+	case *types.Struct:
+		for i := 0; i < x.NumFields(); i++ {
+			if containsType(x.Field(i).Type(), match) {
+				return true
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < x.NumMethods(); i++ {
+			if containsType(x.Method(i).Type(), match) {
+				return true
+			}
+		}
+		for i := 0; i < x.NumEmbeddeds(); i++ {
+			if containsType(x.EmbeddedType(i), match) {
+				return true
+			}
+		}
+	}
+	return false
+}
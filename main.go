@@ -2,61 +2,96 @@ package visualization
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"log"
-	"os"
 	"path/filepath"
 	"strings"
 	"visualization/fileparser"
 )
 
-const (
-	maxDeep = 100
-)
-
-// 递归搜索，找出全部go文件, 并且忽略掉proto生成的文件
-func visit(path string, deep int) ([]string, error) {
-	if deep > maxDeep {
-		return nil, fmt.Errorf("can't visit too deep dirs")
+// 和go build一样跳过testdata以及以.和_开头的目录
+func skipDirReason(name string) string {
+	if name == "testdata" {
+		return "testdata directory"
 	}
-	files := make([]string, 0)
-	rd, err := ioutil.ReadDir(path)
-	if err != nil {
-		log.Printf("can't read dir:%s, error:%s", path, err.Error())
-		return nil, err
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return "directory name starts with . or _"
 	}
-	for _, fi := range rd {
-		subPath := filepath.Join(path, fi.Name())
-		if fi.IsDir() {
-			if fi.Name() != "vendor" {
-				if subFiles, err := visit(subPath, deep+1); err == nil {
-					files = append(files, subFiles...)
-				}
+	return ""
+}
+
+// 递归搜索，找出全部go文件，跳过go build忽略的目录、忽略规则匹配的路径以及不满足构建约束的文件，跳过的路径记录在skipped中
+func visit(root string, config *fileparser.Config, filter *fileparser.FileFilter, skipped map[string]string) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Printf("can't read dir:%s, error:%s", path, err.Error())
+			if path == root {
+				return err
 			}
-		} else {
-			if strings.HasSuffix(subPath, ".go") && !strings.HasSuffix(subPath, ".pb.go") && !strings.HasSuffix(subPath, "_test.go") {
-				files = append(files, subPath)
+			return filepath.SkipDir
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			if reason := skipDirReason(d.Name()); reason != "" {
+				skipped[path] = reason
+				return filepath.SkipDir
 			}
+			if skip, rule := filter.Skip(path, true); skip {
+				skipped[path] = rule
+				return filepath.SkipDir
+			}
+			if rel, err := filepath.Rel(root, path); err == nil && strings.Count(rel, string(filepath.Separator))+1 > config.MaxDepth {
+				skipped[path] = fmt.Sprintf("exceeds max depth %d", config.MaxDepth)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
 		}
+		if skip, rule := filter.Skip(path, false); skip {
+			skipped[path] = rule
+		} else if !config.Tests && strings.HasSuffix(path, "_test.go") {
+			skipped[path] = "test files disabled"
+		} else if !config.MatchFile(path) {
+			skipped[path] = "build constraints"
+		} else {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return files, nil
 }
 
-func NewParser(path string) (fileparser.Parser, error) {
-	files, err := visit(path, 0)
+func NewParser(path string, options ...fileparser.Option) (fileparser.Parser, error) {
+	config := fileparser.NewConfig(options...)
+	skipped := make(map[string]string, 0)
+	files, err := visit(path, config, fileparser.NewFileFilter(path, config), skipped)
 	if err != nil {
 		return nil, err
 	}
 
-	nodeManager := fileparser.NewParser(path)
+	nodeManager := fileparser.NewParser(path, options...)
+	for file, rule := range skipped {
+		nodeManager.Skip(file, rule)
+	}
 	for _, file := range files {
 		err := nodeManager.Inspect(file)
 		if err != nil {
 			log.Printf("can't inspect file:%s, error:%s", file, err.Error())
-			os.Exit(-1)
 		}
 	}
 
+	if err := nodeManager.CheckParseErrors(); err != nil {
+		return nil, err
+	}
+
 	nodeManager.Merge()
 	return nodeManager, nil
 }
@@ -0,0 +1,71 @@
+package visualization
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"visualization/fileparser"
+)
+
+func TestVisit(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"main.go",
+		"main_test.go",
+		"_skip.go",
+		"README.md",
+		"service/service.go",
+		"service/testdata/fixture.go",
+		"testdata/x.go",
+		"_old/old.go",
+		".git/hooks.go",
+		"internal/legacy/legacy.go",
+		"a/b/c/deep.go",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := fileparser.NewConfig(fileparser.WithExclude("internal/legacy"), fileparser.WithMaxDepth(2))
+	skipped := make(map[string]string, 0)
+	files, err := visit(root, config, fileparser.NewFileFilter(root, config), skipped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0)
+	for _, file := range files {
+		rel, _ := filepath.Rel(root, file)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+	if want := []string{"main.go", "service/service.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("visit() = %v, want %v", got, want)
+	}
+
+	gotSkipped := make(map[string]string, 0)
+	for path, reason := range skipped {
+		rel, _ := filepath.Rel(root, path)
+		gotSkipped[filepath.ToSlash(rel)] = reason
+	}
+	wantSkipped := map[string]string{
+		"main_test.go":     "test files disabled",
+		"_skip.go":         "build constraints",
+		"service/testdata": "testdata directory",
+		"testdata":         "testdata directory",
+		"_old":             "directory name starts with . or _",
+		".git":             "directory name starts with . or _",
+		"internal/legacy":  "exclude: internal/legacy",
+		"a/b/c":            "exceeds max depth 2",
+	}
+	if !reflect.DeepEqual(gotSkipped, wantSkipped) {
+		t.Errorf("skipped = %v, want %v", gotSkipped, wantSkipped)
+	}
+}